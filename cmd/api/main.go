@@ -2,21 +2,24 @@ package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
+	"kasir-api/db/migrations"
 	"kasir-api/internal/config"
+	"kasir-api/internal/export"
 	"kasir-api/internal/handlers"
+	"kasir-api/internal/migrate"
+	"kasir-api/internal/models"
 	"kasir-api/internal/repository"
+	"kasir-api/internal/server"
 	"kasir-api/internal/service"
 	"log"
 	"net/http"
 	"os"
+	_ "time/tzdata" // time zones without relying on the container's zoneinfo
 
 	_ "kasir-api/docs" // This will be generated by swag init
 
 	_ "github.com/lib/pq"
-
-	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // @title Kasir API
@@ -31,6 +34,13 @@ func main() {
 	if err != nil {
 		log.Printf("Warning: cannot load config file, relying on environment variables: %v", err)
 	}
+	if cfg.CostingMethod != models.CostingAverage && cfg.CostingMethod != models.CostingFIFO {
+		log.Fatalf("COSTING_METHOD must be %s or %s, got %q", models.CostingAverage, models.CostingFIFO, cfg.CostingMethod)
+	}
+	businessDay, err := service.NewBusinessDay(cfg.Timezone, cfg.BusinessDayCutoff)
+	if err != nil {
+		log.Fatal("TIMEZONE / BUSINESS_DAY_CUTOFF: ", err)
+	}
 
 	// Connect to DB
 	db, err := sql.Open("postgres", cfg.DBUrl)
@@ -45,96 +55,76 @@ func main() {
 	}
 	fmt.Println("Connected to Database!")
 
-	// Create tables if not exist (Temporary solution for development)
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS categories (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			description TEXT
-		);
-
-		CREATE TABLE IF NOT EXISTS products (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			price INT NOT NULL,
-			stock INT NOT NULL
-		);
-
-		ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INT;
-		
-		DO $$ 
-		BEGIN 
-			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_products_categories') THEN 
-				ALTER TABLE products 
-				ADD CONSTRAINT fk_products_categories 
-				FOREIGN KEY (category_id) 
-				REFERENCES categories(id); 
-			END IF; 
-		END $$;
-
-		CREATE TABLE IF NOT EXISTS transactions (
-			id SERIAL PRIMARY KEY,
-			total_amount INT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS transaction_details (
-			id SERIAL PRIMARY KEY,
-			transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
-			product_id INT REFERENCES products(id),
-			quantity INT NOT NULL,
-			subtotal INT NOT NULL
-		);
-	`)
+	// Apply pending schema migrations
+	migrator, err := migrate.New(db, migrations.FS)
 	if err != nil {
-		log.Fatal("cannot create tables:", err)
+		log.Fatal("cannot load migrations:", err)
 	}
 
-	// Setup Routes
-	// Root handler
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		response := map[string]string{
-			"message": "Welcome to Kasir API",
-			"status":  "running",
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
-		json.NewEncoder(w).Encode(response)
-	})
+		return
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatal("cannot apply migrations:", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %d_%s", m.Version, m.Name)
+	}
 
 	// Initialize Repository
 	productRepo := repository.NewPostgresProductRepository(db)
 	categoryRepo := repository.NewPostgresCategoryRepository(db)
-	transactionRepo := repository.NewPostgresTransactionRepository(db)
+	transactionRepo := repository.NewPostgresTransactionRepository(db, cfg.CostingMethod)
+	promotionRepo := repository.NewPostgresPromotionRepository(db)
+	stockMovementRepo := repository.NewPostgresStockMovementRepository(db)
+	stockOpnameRepo := repository.NewPostgresStockOpnameRepository(db)
+	supplierRepo := repository.NewPostgresSupplierRepository(db)
+	purchaseOrderRepo := repository.NewPostgresPurchaseOrderRepository(db)
+	dayClosingRepo := repository.NewPostgresDayClosingRepository(db)
 
 	// Initialize Service
-	productService := service.NewProductService(productRepo)
+	productService := service.NewProductService(productRepo, categoryRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
-	transactionService := service.NewTransactionService(transactionRepo, productRepo)
+	transactionService := service.NewTransactionService(transactionRepo, productRepo, promotionRepo, service.TaxConfig{
+		TaxRate:           cfg.TaxRate,
+		TaxInclusive:      cfg.TaxInclusive,
+		ServiceChargeRate: cfg.ServiceChargeRate,
+	}, businessDay)
+	promotionService := service.NewPromotionService(promotionRepo)
+	stockMovementService := service.NewStockMovementService(stockMovementRepo, productRepo)
+	stockOpnameService := service.NewStockOpnameService(stockOpnameRepo)
+	supplierService := service.NewSupplierService(supplierRepo)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo, productRepo, supplierRepo)
+	dayClosingService := service.NewDayClosingService(dayClosingRepo, businessDay)
 
 	// Initialize Handler
+	store := export.Header{Store: cfg.StoreName, Address: cfg.StoreAddress, Phone: cfg.StorePhone, Location: businessDay.Location()}
 	productHandler := handlers.NewProductHandler(productService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	// GET detail product
-	// PUT update product
-	http.HandleFunc("/api/product/", productHandler.HandleProductDetail)
-	// GET product
-	// POST product
-	http.HandleFunc("/api/product", productHandler.HandleProductList)
-
-	//Category
-	http.HandleFunc("/api/category", categoryHandler.HandleCategoryList)
-	http.HandleFunc("/api/category/", categoryHandler.HandleCategoryDetail)
-
-	// Transaction
-	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout)
-
-	// Report
-	http.HandleFunc("/api/report/hari-ini", transactionHandler.HandleDailyReport)
-	http.HandleFunc("/api/report", transactionHandler.HandleReport)
-
-	// Swagger
-	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, store)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	stockMovementHandler := handlers.NewStockMovementHandler(stockMovementService)
+	stockOpnameHandler := handlers.NewStockOpnameHandler(stockOpnameService)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService, store)
+	dayClosingHandler := handlers.NewDayClosingHandler(dayClosingService, store)
+
+	router := server.New(cfg, server.Handlers{
+		Product:     productHandler,
+		Category:    categoryHandler,
+		Promotion:   promotionHandler,
+		Transaction: transactionHandler,
+		Stock:       stockMovementHandler,
+		Opname:      stockOpnameHandler,
+		Supplier:    supplierHandler,
+		Purchase:    purchaseOrderHandler,
+		Closing:     dayClosingHandler,
+	})
 
 	// Check for PORT env (Railway/Heroku)
 	if port := os.Getenv("PORT"); port != "" {
@@ -142,7 +132,7 @@ func main() {
 	}
 
 	fmt.Printf("server running di %s\n", cfg.ServerAddress)
-	err = http.ListenAndServe(cfg.ServerAddress, nil)
+	err = http.ListenAndServe(cfg.ServerAddress, router)
 	if err != nil {
 		fmt.Printf("gagal running server: %v\n", err)
 	}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/internal/migrate"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [N] | status | force VERSION"
+
+// runMigrate handles `api migrate ...`. down reverts one migration unless a
+// count is given, force only rewrites the recorded version.
+func runMigrate(migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no change")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return errors.New(migrateUsage)
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		version, dirty, statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d (dirty: %t)\n", version, dirty)
+		for _, s := range statuses {
+			mark := " "
+			if s.Applied {
+				mark = "x"
+			}
+			fmt.Printf("[%s] %06d_%s\n", mark, s.Version, s.Name)
+		}
+		return nil
+	case "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errors.New(migrateUsage)
+		}
+		if err := migrator.Force(version); err != nil {
+			return err
+		}
+		fmt.Printf("forced version %d\n", version)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
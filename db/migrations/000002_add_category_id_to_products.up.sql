@@ -1,2 +1,8 @@
 ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INT;
-ALTER TABLE products ADD CONSTRAINT fk_products_categories FOREIGN KEY (category_id) REFERENCES categories(id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_products_categories') THEN
+        ALTER TABLE products ADD CONSTRAINT fk_products_categories FOREIGN KEY (category_id) REFERENCES categories(id);
+    END IF;
+END $$;
@@ -0,0 +1,8 @@
+UPDATE products SET stock = 0 WHERE stock < 0;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_products_stock_non_negative') THEN
+        ALTER TABLE products ADD CONSTRAINT chk_products_stock_non_negative CHECK (stock >= 0);
+    END IF;
+END $$;
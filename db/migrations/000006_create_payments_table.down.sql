@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payments;
+ALTER TABLE transactions DROP COLUMN IF EXISTS change_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS paid_amount;
@@ -0,0 +1,17 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS paid_amount INT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0;
+
+-- Sales recorded before tenders existed were paid exactly
+UPDATE transactions SET paid_amount = total_amount WHERE paid_amount IS NULL;
+ALTER TABLE transactions ALTER COLUMN paid_amount SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'qris', 'debit', 'ewallet')),
+    amount INT NOT NULL CHECK (amount > 0),
+    reference VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments (transaction_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transaction_details_product_id;
+DROP INDEX IF EXISTS idx_transaction_details_transaction_id;
+DROP INDEX IF EXISTS idx_transactions_created_at;
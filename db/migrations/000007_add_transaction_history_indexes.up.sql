@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE INDEX IF NOT EXISTS idx_transaction_details_transaction_id ON transaction_details (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_details_product_id ON transaction_details (product_id);
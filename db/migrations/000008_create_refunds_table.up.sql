@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    type VARCHAR(10) NOT NULL CHECK (type IN ('void', 'refund')),
+    reason TEXT NOT NULL,
+    amount INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id),
+    product_id INT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount INT NOT NULL
+);
+
+-- A transaction can be voided at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_single_void ON refunds (transaction_id) WHERE type = 'void';
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction_id ON refunds (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_created_at ON refunds (created_at);
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items (refund_id);
+CREATE INDEX IF NOT EXISTS idx_refund_items_transaction_detail_id ON refund_items (transaction_detail_id);
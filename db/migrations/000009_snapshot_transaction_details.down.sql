@@ -0,0 +1,9 @@
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_product_id_fkey;
+ALTER TABLE transaction_details
+    ADD CONSTRAINT transaction_details_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id);
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS category_name;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS category_id;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS unit_price;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS product_name;
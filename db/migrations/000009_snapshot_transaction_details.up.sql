@@ -0,0 +1,27 @@
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS product_name VARCHAR(255);
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_id INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_name VARCHAR(255);
+
+-- Best effort backfill: the price actually charged is still in subtotal,
+-- names and categories come from whatever the product looks like today.
+UPDATE transaction_details td
+SET product_name = p.name,
+    category_id = p.category_id,
+    category_name = c.name
+FROM products p
+LEFT JOIN categories c ON c.id = p.category_id
+WHERE td.product_id = p.id AND td.product_name IS NULL;
+
+UPDATE transaction_details SET product_name = '-' WHERE product_name IS NULL;
+UPDATE transaction_details SET unit_price = subtotal / NULLIF(quantity, 0) WHERE unit_price IS NULL;
+UPDATE transaction_details SET unit_price = 0 WHERE unit_price IS NULL;
+
+ALTER TABLE transaction_details ALTER COLUMN product_name SET NOT NULL;
+ALTER TABLE transaction_details ALTER COLUMN unit_price SET NOT NULL;
+
+-- Sold products can be deleted, the snapshot keeps the history readable
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_product_id_fkey;
+ALTER TABLE transaction_details
+    ADD CONSTRAINT transaction_details_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS promotion_name;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS promotion_id;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS cart_promotion_name;
+ALTER TABLE transactions DROP COLUMN IF EXISTS cart_promotion_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gross_amount;
+
+DROP TABLE IF EXISTS promotions;
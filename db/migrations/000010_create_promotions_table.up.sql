@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y', 'min_spend')),
+    percent INT NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount INT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    buy_qty INT NOT NULL DEFAULT 0 CHECK (buy_qty >= 0),
+    get_qty INT NOT NULL DEFAULT 0 CHECK (get_qty >= 0),
+    min_spend INT NOT NULL DEFAULT 0 CHECK (min_spend >= 0),
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    start_time TIME,
+    end_time TIME,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions (active, starts_at, ends_at);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS gross_amount INT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS cart_promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS cart_promotion_name VARCHAR(255);
+UPDATE transactions SET gross_amount = total_amount WHERE gross_amount IS NULL;
+ALTER TABLE transactions ALTER COLUMN gross_amount SET NOT NULL;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS promotion_name VARCHAR(255);
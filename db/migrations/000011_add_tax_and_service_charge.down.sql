@@ -0,0 +1,15 @@
+ALTER TABLE refund_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE refund_items DROP COLUMN IF EXISTS tax_base;
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_base;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_inclusive;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_rate;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS subtotal;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_inclusive;
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_rate;
@@ -0,0 +1,22 @@
+-- Per category override of the outlet tax rule, NULL falls back to the outlet
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal INT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0;
+UPDATE transactions SET subtotal = total_amount WHERE subtotal IS NULL;
+ALTER TABLE transactions ALTER COLUMN subtotal SET NOT NULL;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_base INT;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0;
+UPDATE transaction_details SET tax_base = subtotal WHERE tax_base IS NULL;
+ALTER TABLE transaction_details ALTER COLUMN tax_base SET NOT NULL;
+
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS tax_base INT;
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+UPDATE refund_items SET tax_base = amount WHERE tax_base IS NULL;
+ALTER TABLE refund_items ALTER COLUMN tax_base SET NOT NULL;
@@ -0,0 +1,9 @@
+-- Back the product listing filters and sort keys
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price);
+CREATE INDEX IF NOT EXISTS idx_products_stock ON products (stock);
+CREATE INDEX IF NOT EXISTS idx_products_name ON products (name);
+
+-- Trigram index so the name ILIKE '%...%' search does not scan every product
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
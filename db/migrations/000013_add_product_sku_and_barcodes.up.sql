@@ -0,0 +1,11 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+
+-- Barcodes are stored as 13 digit EAN-13, UPC-A codes get a leading zero.
+-- The primary key doubles as the scan lookup index.
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    code VARCHAR(13) PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes (product_id);
@@ -0,0 +1,8 @@
+ALTER TABLE transaction_details
+    DROP COLUMN IF EXISTS variant_name,
+    DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE product_barcodes DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
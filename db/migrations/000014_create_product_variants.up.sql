@@ -0,0 +1,33 @@
+-- Option types such as size or flavour, values in display order.
+CREATE TABLE IF NOT EXISTS product_options (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    choices TEXT[] NOT NULL,
+    CONSTRAINT uq_product_options_name UNIQUE (product_id, name)
+);
+
+-- A variant holds one value of every option of its product, in option order.
+-- products.stock of a product with variants is the sum of its variants'
+-- stock. The unique constraints are deferred so an update can swap the
+-- values of two variants.
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    option_values TEXT[] NOT NULL,
+    sku VARCHAR(64),
+    price INT NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    CONSTRAINT uq_product_variants_options UNIQUE (product_id, option_values) DEFERRABLE INITIALLY DEFERRED,
+    CONSTRAINT uq_product_variants_sku UNIQUE (sku) DEFERRABLE INITIALLY DEFERRED,
+    CONSTRAINT chk_product_variants_stock_non_negative CHECK (stock >= 0)
+);
+
+-- Product level barcodes have no variant.
+ALTER TABLE product_barcodes
+    ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+ALTER TABLE transaction_details
+    ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS variant_name VARCHAR(255);
@@ -0,0 +1,30 @@
+-- Every stock change, signed. products.stock and product_variants.stock are
+-- the running sums of this table, balance is the stock of the product, or of
+-- the variant for a variant movement, right after the movement.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INT REFERENCES product_variants(id) ON DELETE SET NULL,
+    quantity INT NOT NULL,
+    balance INT NOT NULL,
+    reason VARCHAR(16) NOT NULL,
+    reference_id INT,
+    note VARCHAR(255),
+    created_by VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_stock_movements_reason CHECK (reason IN ('sale', 'refund', 'adjustment', 'purchase', 'transfer')),
+    CONSTRAINT chk_stock_movements_quantity CHECK (quantity <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, id DESC);
+
+-- Opening balances, so the ledger adds up to the stock on hand today
+INSERT INTO stock_movements (product_id, variant_id, quantity, balance, reason, note)
+SELECT v.product_id, v.id, v.stock, v.stock, 'adjustment', 'opening balance'
+FROM product_variants v
+WHERE v.stock <> 0;
+
+INSERT INTO stock_movements (product_id, quantity, balance, reason, note)
+SELECT p.id, p.stock, p.stock, 'adjustment', 'opening balance'
+FROM products p
+WHERE p.stock <> 0 AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS stock_opnames (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    note VARCHAR(255),
+    created_by VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_by VARCHAR(64),
+    closed_at TIMESTAMP,
+    CONSTRAINT chk_stock_opnames_status CHECK (status IN ('open', 'posted', 'cancelled'))
+);
+
+-- One count at a time, two open sessions would adjust the same stock twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opnames_open ON stock_opnames ((TRUE)) WHERE status = 'open';
+
+-- expected is the stock when the session was opened. Posting adjusts by
+-- counted - expected, so sales made while counting are not undone.
+CREATE TABLE IF NOT EXISTS stock_opname_items (
+    id SERIAL PRIMARY KEY,
+    opname_id INT NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+    variant_name VARCHAR(255),
+    expected INT NOT NULL,
+    counted INT,
+    unit_value INT NOT NULL,
+    counted_by VARCHAR(64),
+    counted_at TIMESTAMP,
+    CONSTRAINT chk_stock_opname_items_counted CHECK (counted >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opname_items_line
+    ON stock_opname_items (opname_id, product_id, COALESCE(variant_id, 0));
@@ -0,0 +1,7 @@
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS unit_cost;
+
+DROP TABLE IF EXISTS goods_receipt_items;
+DROP TABLE IF EXISTS goods_receipts;
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS suppliers;
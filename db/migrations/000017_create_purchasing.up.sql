@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255),
+    phone VARCHAR(32),
+    email VARCHAR(255),
+    address TEXT
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    note VARCHAR(255),
+    created_by VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_purchase_orders_suppliers FOREIGN KEY (supplier_id) REFERENCES suppliers(id),
+    CONSTRAINT chk_purchase_orders_status CHECK (status IN ('open', 'partially_received', 'received', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders (supplier_id, status);
+
+-- Lines keep the product name like transaction_details, so a deleted product
+-- does not blank out an order.
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    variant_id INT REFERENCES product_variants(id) ON DELETE SET NULL,
+    variant_name VARCHAR(255),
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL,
+    received_quantity INT NOT NULL DEFAULT 0,
+    CONSTRAINT chk_purchase_order_lines_quantity CHECK (quantity > 0 AND unit_cost >= 0),
+    CONSTRAINT chk_purchase_order_lines_received CHECK (received_quantity BETWEEN 0 AND quantity)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_order ON purchase_order_lines (purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    note VARCHAR(255),
+    created_by VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_items (
+    id SERIAL PRIMARY KEY,
+    goods_receipt_id INT NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    purchase_order_line_id INT NOT NULL REFERENCES purchase_order_lines(id) ON DELETE CASCADE,
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL,
+    CONSTRAINT chk_goods_receipt_items_quantity CHECK (quantity > 0 AND unit_cost >= 0)
+);
+
+-- What a unit cost when it came in, purchases only
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS unit_cost INT;
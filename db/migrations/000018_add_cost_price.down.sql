@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS stock_cost_layers;
+
+ALTER TABLE refund_items DROP COLUMN IF EXISTS cost_amount;
+ALTER TABLE transaction_details
+    DROP COLUMN IF EXISTS cost_amount,
+    DROP COLUMN IF EXISTS unit_cost;
+
+ALTER TABLE product_variants DROP COLUMN IF EXISTS cost_price;
+ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
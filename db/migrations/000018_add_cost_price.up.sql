@@ -0,0 +1,61 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0;
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0;
+
+-- What the units of a line cost at sale time, by the costing method in use,
+-- and the part of it given back by a refund
+ALTER TABLE transaction_details
+    ADD COLUMN IF NOT EXISTS unit_cost INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
+
+-- Every batch of stock that came in with what it cost, remaining is what is
+-- left of it for FIFO costing. Layers are kept whatever the costing method.
+CREATE TABLE IF NOT EXISTS stock_cost_layers (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity INT NOT NULL,
+    remaining INT NOT NULL,
+    unit_cost INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_stock_cost_layers_remaining CHECK (remaining BETWEEN 0 AND quantity)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layers_open ON stock_cost_layers (product_id, id) WHERE remaining > 0;
+
+-- Start from the last purchase cost where goods were received already
+UPDATE products p SET cost_price = m.unit_cost
+FROM (
+    SELECT DISTINCT ON (product_id) product_id, unit_cost
+    FROM stock_movements
+    WHERE reason = 'purchase' AND variant_id IS NULL AND unit_cost IS NOT NULL
+    ORDER BY product_id, id DESC
+) m
+WHERE m.product_id = p.id;
+
+UPDATE product_variants v SET cost_price = m.unit_cost
+FROM (
+    SELECT DISTINCT ON (variant_id) variant_id, unit_cost
+    FROM stock_movements
+    WHERE reason = 'purchase' AND variant_id IS NOT NULL AND unit_cost IS NOT NULL
+    ORDER BY variant_id, id DESC
+) m
+WHERE m.variant_id = v.id;
+
+-- Earlier sales are costed at the same price, the best guess there is
+UPDATE transaction_details td
+SET unit_cost = COALESCE(v.cost_price, p.cost_price), cost_amount = COALESCE(v.cost_price, p.cost_price) * td.quantity
+FROM products p
+LEFT JOIN product_variants v ON v.product_id = p.id
+WHERE p.id = td.product_id AND v.id IS NOT DISTINCT FROM td.variant_id;
+
+-- One opening layer for the stock on hand
+INSERT INTO stock_cost_layers (product_id, variant_id, quantity, remaining, unit_cost)
+SELECT v.product_id, v.id, v.stock, v.stock, v.cost_price
+FROM product_variants v
+WHERE v.stock > 0;
+
+INSERT INTO stock_cost_layers (product_id, quantity, remaining, unit_cost)
+SELECT p.id, p.stock, p.stock, p.cost_price
+FROM products p
+WHERE p.stock > 0 AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id);
@@ -0,0 +1,14 @@
+ALTER TABLE stock_cost_layers ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE goods_receipts ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE purchase_orders ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE stock_opname_items ALTER COLUMN counted_at TYPE TIMESTAMP;
+ALTER TABLE stock_opnames
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN closed_at TYPE TIMESTAMP;
+ALTER TABLE stock_movements ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE promotions
+    ALTER COLUMN starts_at TYPE TIMESTAMP,
+    ALTER COLUMN ends_at TYPE TIMESTAMP;
+ALTER TABLE refunds ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE payments ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMP;
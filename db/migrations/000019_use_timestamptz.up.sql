@@ -0,0 +1,19 @@
+-- Store every moment with its time zone. The rows so far were written with
+-- NOW() into columns without one, i.e. as wall clock time of the database
+-- time zone, which is exactly how the conversion reads them. Promotion bounds
+-- entered by hand are read the same way and may want checking afterwards if
+-- the database does not run in the store's time zone.
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE payments ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE refunds ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE promotions
+    ALTER COLUMN starts_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN ends_at TYPE TIMESTAMPTZ;
+ALTER TABLE stock_movements ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE stock_opnames
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN closed_at TYPE TIMESTAMPTZ;
+ALTER TABLE stock_opname_items ALTER COLUMN counted_at TYPE TIMESTAMPTZ;
+ALTER TABLE purchase_orders ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE goods_receipts ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE stock_cost_layers ALTER COLUMN created_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_refunds_business_day_open ON refunds;
+DROP TRIGGER IF EXISTS trg_payments_business_day_open ON payments;
+DROP TRIGGER IF EXISTS trg_transactions_business_day_open ON transactions;
+DROP FUNCTION IF EXISTS reject_closed_business_day();
+
+DROP TABLE IF EXISTS day_closing_payments;
+DROP TABLE IF EXISTS day_closings;
+DROP FUNCTION IF EXISTS reject_day_closing_change();
@@ -0,0 +1,88 @@
+-- A closed business day, the Z report. The totals are worked out once when
+-- the day is closed and never change afterwards: the row cannot be updated
+-- or deleted, and no sale, payment or refund can be recorded, changed or
+-- removed within [period_start, period_end) any more.
+CREATE TABLE IF NOT EXISTS day_closings (
+    id SERIAL PRIMARY KEY,
+    business_date DATE NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    transactions INT NOT NULL,
+    gross_sales INT NOT NULL,
+    total_discount INT NOT NULL,
+    total_tax INT NOT NULL,
+    total_service_charge INT NOT NULL,
+    total_sales INT NOT NULL,
+    refunds INT NOT NULL,
+    total_refund INT NOT NULL,
+    total_revenue INT NOT NULL,
+    opening_cash INT NOT NULL,
+    cash_sales INT NOT NULL,
+    expected_cash INT NOT NULL,
+    counted_cash INT NOT NULL,
+    cash_difference INT NOT NULL,
+    note VARCHAR(255),
+    closed_by VARCHAR(64),
+    closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_day_closings_business_date UNIQUE (business_date),
+    CONSTRAINT chk_day_closings_period CHECK (period_end > period_start),
+    CONSTRAINT chk_day_closings_cash CHECK (opening_cash >= 0 AND counted_cash >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_day_closings_period ON day_closings (period_start, period_end);
+
+-- The money taken per payment method on the closed day, cash net of change
+CREATE TABLE IF NOT EXISTS day_closing_payments (
+    day_closing_id INT NOT NULL REFERENCES day_closings(id),
+    method VARCHAR(20) NOT NULL,
+    transactions INT NOT NULL,
+    amount INT NOT NULL,
+    PRIMARY KEY (day_closing_id, method)
+);
+
+CREATE OR REPLACE FUNCTION reject_day_closing_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'a closed business day cannot be changed'
+        USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_day_closings_immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_day_closings_immutable
+    BEFORE UPDATE OR DELETE ON day_closings
+    FOR EACH ROW EXECUTE FUNCTION reject_day_closing_change();
+
+CREATE TRIGGER trg_day_closing_payments_immutable
+    BEFORE UPDATE OR DELETE ON day_closing_payments
+    FOR EACH ROW EXECUTE FUNCTION reject_day_closing_change();
+
+-- Keeps the rows a closing was worked out from as they were. Both the old and
+-- the new created_at are checked, so a row can neither be moved into nor out
+-- of a closed day.
+CREATE OR REPLACE FUNCTION reject_closed_business_day() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM day_closings WHERE OLD.created_at >= period_start AND OLD.created_at < period_end
+    ) OR TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM day_closings WHERE NEW.created_at >= period_start AND NEW.created_at < period_end
+    ) THEN
+        RAISE EXCEPTION 'the business day of this % row has been closed', TG_TABLE_NAME
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_business_day_open';
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_transactions_business_day_open
+    BEFORE INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION reject_closed_business_day();
+
+CREATE TRIGGER trg_payments_business_day_open
+    BEFORE INSERT OR UPDATE OR DELETE ON payments
+    FOR EACH ROW EXECUTE FUNCTION reject_closed_business_day();
+
+CREATE TRIGGER trg_refunds_business_day_open
+    BEFORE INSERT OR UPDATE OR DELETE ON refunds
+    FOR EACH ROW EXECUTE FUNCTION reject_closed_business_day();
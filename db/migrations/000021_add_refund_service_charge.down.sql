@@ -0,0 +1 @@
+ALTER TABLE refund_items DROP COLUMN IF EXISTS service_charge;
@@ -0,0 +1,8 @@
+-- The part of the line's service charge given back by a refund, so reports
+-- can net service charge like they net tax. Refunds made so far get their
+-- share prorated by quantity.
+ALTER TABLE refund_items ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0;
+
+UPDATE refund_items ri SET service_charge = td.service_charge * ri.quantity / td.quantity
+FROM transaction_details td
+WHERE td.id = ri.transaction_detail_id AND td.quantity > 0;
@@ -0,0 +1,9 @@
+-- Movements of deleted products cannot be linked back and are dropped
+DELETE FROM stock_movements WHERE product_id IS NULL;
+
+ALTER TABLE stock_movements
+    DROP CONSTRAINT IF EXISTS stock_movements_product_id_fkey,
+    ADD CONSTRAINT stock_movements_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    ALTER COLUMN product_id SET NOT NULL,
+    DROP COLUMN IF EXISTS variant_name,
+    DROP COLUMN IF EXISTS product_name;
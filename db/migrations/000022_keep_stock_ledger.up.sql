@@ -0,0 +1,20 @@
+-- The stock ledger is the audit trail of every stock change and outlives the
+-- product: deleting a product now only unlinks its movements, which keep the
+-- product and variant name like transaction_details.
+ALTER TABLE stock_movements
+    ADD COLUMN IF NOT EXISTS product_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS variant_name VARCHAR(255);
+
+UPDATE stock_movements m SET product_name = p.name
+FROM products p
+WHERE p.id = m.product_id;
+
+UPDATE stock_movements m SET variant_name = array_to_string(v.option_values, ' / ')
+FROM product_variants v
+WHERE v.id = m.variant_id;
+
+ALTER TABLE stock_movements
+    ALTER COLUMN product_name SET NOT NULL,
+    ALTER COLUMN product_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS stock_movements_product_id_fkey,
+    ADD CONSTRAINT stock_movements_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE day_closings DROP COLUMN IF EXISTS cash_refund;
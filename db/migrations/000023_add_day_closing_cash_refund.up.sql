@@ -0,0 +1,4 @@
+-- The part of the day's refunds paid back from the drawer: the cash share of
+-- the refunded sales. Days closed before took every refund off the expected
+-- cash and keep 0 here.
+ALTER TABLE day_closings ADD COLUMN IF NOT EXISTS cash_refund INT NOT NULL DEFAULT 0;
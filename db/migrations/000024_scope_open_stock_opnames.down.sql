@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_stock_opnames_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opnames_open ON stock_opnames ((TRUE)) WHERE status = 'open';
@@ -0,0 +1,5 @@
+-- Counts of different categories can run side by side, a category is counted
+-- once at a time. That a whole catalogue count excludes every other one is
+-- checked when a session is opened.
+DROP INDEX IF EXISTS idx_stock_opnames_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opnames_open ON stock_opnames ((COALESCE(category_id, 0))) WHERE status = 'open';
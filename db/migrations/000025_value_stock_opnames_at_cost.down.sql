@@ -0,0 +1,7 @@
+UPDATE stock_opname_items i
+SET unit_cost = COALESCE(v.price, p.price)
+FROM products p
+LEFT JOIN product_variants v ON v.product_id = p.id
+WHERE p.id = i.product_id AND v.id IS NOT DISTINCT FROM i.variant_id;
+
+ALTER TABLE stock_opname_items RENAME COLUMN unit_cost TO unit_value;
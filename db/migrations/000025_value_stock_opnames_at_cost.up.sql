@@ -0,0 +1,10 @@
+-- Variances are valued at what the stock cost, not at what it sells for
+ALTER TABLE stock_opname_items RENAME COLUMN unit_value TO unit_cost;
+
+-- Earlier sessions are revalued at the current cost price, the best guess
+-- there is
+UPDATE stock_opname_items i
+SET unit_cost = COALESCE(v.cost_price, p.cost_price)
+FROM products p
+LEFT JOIN product_variants v ON v.product_id = p.id
+WHERE p.id = i.product_id AND v.id IS NOT DISTINCT FROM i.variant_id;
@@ -37,6 +37,12 @@ const docTemplate = `{
                                 "$ref": "#/definitions/kasir-api_internal_models.Category"
                             }
                         }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -71,9 +77,15 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid request",
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED with every violation in details",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
@@ -109,15 +121,15 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid id",
+                        "description": "INVALID_PARAMETER",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "category not found",
+                        "description": "CATEGORY_NOT_FOUND",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
@@ -160,15 +172,15 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid request/id",
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "category not found",
+                        "description": "CATEGORY_NOT_FOUND",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
@@ -199,15 +211,21 @@ const docTemplate = `{
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "invalid id",
+                        "description": "INVALID_PARAMETER",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "category not found",
+                        "description": "CATEGORY_NOT_FOUND",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "CATEGORY_IN_USE",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
@@ -215,7 +233,7 @@ const docTemplate = `{
         },
         "/checkout": {
             "post": {
-                "description": "Create a new transaction with multiple items",
+                "description": "Create a new transaction with multiple items, priced with the promotions running at checkout, taxed with the category or outlet tax rule plus the outlet service charge, and paid with one or more tenders (cash, qris, debit, ewallet). Only cash may exceed the total; the difference is returned as kembalian. Items name the product by product_id or by a scanned barcode, plus variant_id for a product with variants unless the barcode is the variant's own. Retries carrying the same idempotency key return the transaction created by the first attempt.",
                 "consumes": [
                     "application/json"
                 ],
@@ -227,6 +245,12 @@ const docTemplate = `{
                 ],
                 "summary": "Create a new transaction (Checkout)",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client generated key, may also be sent as idempotency_key in the body",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
                     {
                         "description": "Checkout Data",
                         "name": "checkout",
@@ -245,23 +269,35 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "INVALID_REQUEST, VALIDATION_FAILED (empty items, quantities below 1, duplicate products, malformed barcodes) or INSUFFICIENT_PAYMENT",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "INSUFFICIENT_STOCK with every short item in items, IDEMPOTENCY_KEY_REUSED, or BUSINESS_DAY_CLOSED once the current business day has been closed",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/product": {
+        "/closing": {
             "get": {
-                "description": "Get list of all product",
+                "description": "List the Z reports of the closed business days, latest first. The payments per method are only part of a single Z report.",
                 "consumes": [
                     "application/json"
                 ],
@@ -269,14 +305,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "product"
+                    "report"
                 ],
-                "summary": "Get all product",
+                "summary": "List closed business days",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product Name Filter",
-                        "name": "name",
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 20, max 100)",
+                        "name": "per_page",
                         "in": "query"
                     }
                 ],
@@ -284,16 +326,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/kasir-api_internal_models.Product"
-                            }
+                            "$ref": "#/definitions/kasir-api_internal_models.DayClosingList"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new product",
+                "description": "Close the current business day, or an earlier one left open, with the cash counted in the drawer. The day's sales, refunds and payments are totalled and stored with the count as the Z report, which never changes afterwards; from then on no sale, payment or refund can be recorded in the day, checkouts and refunds get BUSINESS_DAY_CLOSED. Sales being recorded while closing are waited for and included.",
                 "consumes": [
                     "application/json"
                 ],
@@ -301,17 +352,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "product"
+                    "report"
                 ],
-                "summary": "Create new product",
+                "summary": "Close a business day (Z report)",
                 "parameters": [
                     {
-                        "description": "Product Data",
-                        "name": "product",
+                        "type": "string",
+                        "description": "User recorded as closing the day",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Business day, opening float and counted cash",
+                        "name": "closing",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                            "$ref": "#/definitions/kasir-api_internal_models.DayClosingRequest"
                         }
                     }
                 ],
@@ -319,63 +376,156 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                            "$ref": "#/definitions/kasir-api_internal_models.DayClosing"
                         }
                     },
                     "400": {
-                        "description": "invalid request",
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED, also for a business day that has not started yet",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "DAY_ALREADY_CLOSED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/product/{id}": {
+        "/closing/x": {
             "get": {
-                "description": "Get detail of a product by ID",
+                "description": "Total the current business day, or an earlier one, as it stands without closing it; it can be printed as often as needed and changes as sales come in. With opening_cash and counted_cash it also checks the drawer. With format=csv, xlsx or pdf the report is a file, the PDF a printable summary under the store header.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
                 ],
                 "tags": [
-                    "product"
+                    "report"
                 ],
-                "summary": "Get detail product",
+                "summary": "Get the X report of a business day",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Business day as YYYY-MM-DD, the current one by default",
+                        "name": "date",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Product ID",
-                        "name": "id",
+                        "description": "Cash in the drawer when the day started",
+                        "name": "opening_cash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cash counted in the drawer now",
+                        "name": "counted_cash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.DayClosing"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED, also for a business day that has not started yet",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/closing/{date}": {
+            "get": {
+                "description": "Reprint the Z report stored when the business day was closed, exactly as it was then. With format=csv, xlsx or pdf the report is a file, the PDF a printable summary under the store header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Get the Z report of a closed business day",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Business day as YYYY-MM-DD",
+                        "name": "date",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                            "$ref": "#/definitions/kasir-api_internal_models.DayClosing"
                         }
                     },
                     "400": {
-                        "description": "invalid id",
+                        "description": "INVALID_PARAMETER",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "product not found",
+                        "description": "DAY_CLOSING_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing product",
+            }
+        },
+        "/product": {
+            "get": {
+                "description": "Get a page of products with the total count and a link to the next page",
                 "consumes": [
                     "application/json"
                 ],
@@ -385,48 +535,80 @@ const docTemplate = `{
                 "tags": [
                     "product"
                 ],
-                "summary": "Update product",
+                "summary": "Get all product",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product Name Filter",
+                        "name": "name",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Product ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only products in this category",
+                        "name": "category_id",
+                        "in": "query"
                     },
                     {
-                        "description": "Product Data",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.Product"
-                        }
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true for products with stock, false for sold out ones",
+                        "name": "in_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated id, name, price or stock, prefix - for descending (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 200)",
+                        "name": "per_page",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                            "$ref": "#/definitions/kasir-api_internal_models.ProductList"
                         }
                     },
                     "400": {
-                        "description": "invalid request/id",
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown sort key",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "product not found",
+                    "500": {
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete a product by ID",
+            "post": {
+                "description": "Create a new product, optionally with option types (size, color, flavour) and a variant with its own price, stock and barcodes per combination",
                 "consumes": [
                     "application/json"
                 ],
@@ -436,44 +618,49 @@ const docTemplate = `{
                 "tags": [
                     "product"
                 ],
-                "summary": "Delete product",
+                "summary": "Create new product",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Product ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Product Data",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
                         }
                     },
                     "400": {
-                        "description": "invalid id",
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED with every violation in details",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "product not found",
+                    "409": {
+                        "description": "DUPLICATE_SKU or DUPLICATE_BARCODE",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/report": {
+        "/product/barcode/{code}": {
             "get": {
-                "description": "Get total revenue, total transactions, and best selling product for a specific date range",
+                "description": "Look up the product a scanned EAN-13 or UPC-A barcode belongs to. For a variant barcode, variants holds only the scanned variant.",
                 "consumes": [
                     "application/json"
                 ],
@@ -481,22 +668,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "report"
+                    "product"
                 ],
-                "summary": "Get sales report by date range",
+                "summary": "Find product by barcode",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start Date (YYYY-MM-DD)",
-                        "name": "start_date",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "End Date (YYYY-MM-DD)",
-                        "name": "end_date",
-                        "in": "query",
+                        "description": "EAN-13 or UPC-A barcode",
+                        "name": "code",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -504,27 +684,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.SalesReport"
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
                         }
                     },
                     "400": {
-                        "description": "Invalid date format",
+                        "description": "VALIDATION_FAILED for a malformed barcode",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/report/hari-ini": {
+        "/product/{id}": {
             "get": {
-                "description": "Get total revenue, total transactions, and best selling product for today",
+                "description": "Get detail of a product by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -532,107 +712,3313 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "report"
+                    "product"
+                ],
+                "summary": "Get detail product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get daily sales report",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/kasir-api_internal_models.SalesReport"
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "INVALID_PARAMETER",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "kasir-api_internal_models.Category": {
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
+            },
+            "put": {
+                "description": "Update an existing product. Options and variants are replaced together; variants with an id are updated, those without are added and the ones left out are removed. Stock is not changed, except for the opening stock of new variants; adjust it through POST /api/stock-movements.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Update product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product Data",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER, or VALIDATION_FAILED with every violation in details",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "DUPLICATE_SKU or DUPLICATE_BARCODE",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a product by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Delete product",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/promotion": {
+            "get": {
+                "description": "Get list of all promotions, running or not",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotion"
+                ],
+                "summary": "Get all promotions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a percentage, fixed, buy_x_get_y or min_spend promotion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotion"
+                ],
+                "summary": "Create new promotion",
+                "parameters": [
+                    {
+                        "description": "Promotion Data",
+                        "name": "promotion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/promotion/{id}": {
+            "get": {
+                "description": "Get detail of a promotion by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotion"
+                ],
+                "summary": "Get detail promotion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PROMOTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing promotion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotion"
+                ],
+                "summary": "Update promotion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Promotion Data",
+                        "name": "promotion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Promotion"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PROMOTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a promotion by ID. Transactions keep the name of the promotions they used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promotion"
+                ],
+                "summary": "Delete promotion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PROMOTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase-orders": {
+            "get": {
+                "description": "List purchase orders newest first, without their lines",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "List purchase orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only orders of this supplier",
+                        "name": "supplier_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "open, partially_received, received or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 20, max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrderList"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Order products from a supplier. Products with variants are ordered per variant. Status, names and received quantities are filled in by the server.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Create a purchase order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User recorded on the order",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Supplier, note and lines",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED, also for an unknown supplier or product",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase-orders/{id}": {
+            "get": {
+                "description": "Get a purchase order with its lines and goods receipts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Get a purchase order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PURCHASE_ORDER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the supplier, note and lines of an order nothing has been received against yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Update a purchase order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Supplier, note and lines",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PURCHASE_ORDER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PURCHASE_ORDER_NOT_EDITABLE",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase-orders/{id}/cancel": {
+            "post": {
+                "description": "Close the order, whatever is still outstanding will not be delivered. Goods already received stay in stock.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Cancel a purchase order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PURCHASE_ORDER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PURCHASE_ORDER_CLOSED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase-orders/{id}/receipts": {
+            "post": {
+                "description": "Post a goods receipt for some or all of the outstanding quantities. Stock goes up through the stock ledger at the received unit cost, which defaults to the ordered one. The order becomes received once every line is complete.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Receive goods against a purchase order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User recorded on the receipt and the stock movements",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Received quantities",
+                        "name": "receipt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.GoodsReceiptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED, also for a line of another order or more than is outstanding",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PURCHASE_ORDER_NOT_FOUND, or PRODUCT_NOT_FOUND when the product of a line has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PURCHASE_ORDER_CLOSED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/report": {
+            "get": {
+                "description": "Get total revenue (net of refunds made in the range), total transactions, best selling product, and COGS, gross profit and margin overall, per product and per category for a range of business days, each running from the configured cutoff in the store time zone to the next day's cutoff. More sections can be asked for with include. With format=csv, xlsx or pdf the report is a file with one table per part; the PDF is a printable summary under the store header, titled as the monthly report for a whole calendar month.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Get sales report by date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First business day (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last business day, included (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated extra sections: products, categories, heatmap, payments, basket",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Products listed by the products section (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.SalesReport"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown section",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/hari-ini": {
+            "get": {
+                "description": "Get total revenue (net of refunds made today), total transactions, best selling product, and COGS, gross profit and margin overall, per product and per category for the business day running now, in the store time zone and from the configured cutoff. More sections can be asked for with include. With format=csv, xlsx or pdf the report is a file with one table per part; the PDF is a printable summary under the store header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Get daily sales report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated extra sections: products, categories, heatmap, payments, basket",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Products listed by the products section (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.SalesReport"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown section",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/outstanding-po": {
+            "get": {
+                "description": "What every supplier still has to deliver on its open and partially received orders, in quantity and value at the ordered unit costs. With format=csv, xlsx or pdf it is a file with the totals per supplier and the orders behind them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Outstanding purchase orders per supplier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only this supplier",
+                        "name": "supplier_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/kasir-api_internal_models.OutstandingSupplier"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "SUPPLIER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-movements": {
+            "get": {
+                "description": "List the stock ledger newest first: every sale, refund, adjustment, purchase and transfer with the stock left after it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "List stock movements",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only movements of this product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movements of this variant",
+                        "name": "variant_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sale, refund, adjustment, purchase or transfer",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 200)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockMovementList"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown reason",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adjust the stock of a product, or of one of its variants, or record a transfer. The quantity is signed, negative takes stock out. The user is taken from the X-User-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Record a stock movement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User recorded on the movement",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Movement",
+                        "name": "movement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockMovementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockMovement"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED with every violation in details",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PRODUCT_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "INSUFFICIENT_STOCK when the movement would take stock below zero",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-opnames": {
+            "get": {
+                "description": "List stock count sessions newest first with their variance summary",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "List stock opname sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "open, posted or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 20, max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpnameList"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER or VALIDATION_FAILED for an unknown status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start a stock count of the whole catalogue or of one category. The stock of every product, or of every variant for products with variants, is frozen as the expected quantity. Sessions of different categories can be open at the same time; a category, and the whole catalogue, is counted by one session at a time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Open a stock opname session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User recorded on the session",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Scope and note",
+                        "name": "opname",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpnameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "STOCK_OPNAME_OPEN when an open session counts the same products",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-opnames/{id}": {
+            "get": {
+                "description": "Get a stock count session with every line and the variance in quantity and in value at cost price. variance_only=true keeps the counted lines that differ from the expected quantity.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Get a stock opname session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stock opname ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only lines with a variance",
+                        "name": "variance_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "STOCK_OPNAME_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-opnames/{id}/cancel": {
+            "post": {
+                "description": "Close the session without touching stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Cancel a stock opname session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stock opname ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "STOCK_OPNAME_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "STOCK_OPNAME_CLOSED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-opnames/{id}/counts": {
+            "put": {
+                "description": "Record the counted quantity of products or variants of an open session. Counting a line again replaces the earlier count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Record counted quantities",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stock opname ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User recorded on the counted lines",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Counted quantities",
+                        "name": "counts",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockCountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED, also for a product that is not part of the session",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "STOCK_OPNAME_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "STOCK_OPNAME_CLOSED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stock-opnames/{id}/post": {
+            "post": {
+                "description": "Close the session and adjust the stock of every counted line by counted minus expected through the stock ledger. Uncounted lines and every other product field are left alone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Post a stock opname session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stock opname ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User recorded on the adjustments",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "STOCK_OPNAME_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "STOCK_OPNAME_CLOSED, or INSUFFICIENT_STOCK when more was sold while counting than the count leaves",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/suppliers": {
+            "get": {
+                "description": "Get list of all suppliers ordered by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Get all suppliers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new supplier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Create new supplier",
+                "parameters": [
+                    {
+                        "description": "Supplier Data",
+                        "name": "supplier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST or VALIDATION_FAILED with every violation in details",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/suppliers/{id}": {
+            "get": {
+                "description": "Get detail of a supplier by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Get detail supplier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Supplier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "SUPPLIER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing supplier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Update supplier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Supplier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Supplier Data",
+                        "name": "supplier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "SUPPLIER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a supplier by ID, only possible while it has no purchase orders",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "Delete supplier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Supplier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "SUPPLIER_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "SUPPLIER_IN_USE",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions": {
+            "get": {
+                "description": "List transactions newest first, with their details and payments. With format=csv, xlsx or pdf every matching transaction is streamed as a file with one row per transaction, page and per_page are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "transaction"
+                ],
+                "summary": "List transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First business day (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last business day, included (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum total amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum total amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only transactions containing this product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 20, max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.TransactionList"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}": {
+            "get": {
+                "description": "Get a transaction by ID with its details and payments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transaction"
+                ],
+                "summary": "Get detail transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_PARAMETER",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "TRANSACTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}/refund": {
+            "post": {
+                "description": "Return selected quantities of transaction details. The quantities go back into stock; the original transaction is kept unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transaction"
+                ],
+                "summary": "Refund part of a transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund Data",
+                        "name": "refund",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "TRANSACTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "TRANSACTION_VOIDED, NOTHING_TO_REFUND, or BUSINESS_DAY_CLOSED once the current business day has been closed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}/void": {
+            "post": {
+                "description": "Cancel a whole transaction. Every quantity not refunded yet goes back into stock; the original transaction is kept unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transaction"
+                ],
+                "summary": "Void a transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Void reason",
+                        "name": "void",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.VoidRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/kasir-api_internal_models.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "TRANSACTION_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "TRANSACTION_VOIDED, NOTHING_TO_REFUND, or BUSINESS_DAY_CLOSED once the current business day has been closed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "INTERNAL_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_handlers.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "PRODUCT_NOT_FOUND"
+                },
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_handlers.FieldError"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.StockShortage"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "product not found"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b9c1e8a7d4f60"
+                }
+            }
+        },
+        "internal_handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/internal_handlers.APIError"
+                }
+            }
+        },
+        "internal_handlers.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "category_id"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "category_id does not refer to an existing category"
+                }
+            }
+        },
+        "kasir-api_internal_models.BasketSummary": {
+            "type": "object",
+            "properties": {
+                "average_basket": {
+                    "type": "integer"
+                },
+                "items_per_transaction": {
+                    "type": "number"
+                }
+            }
+        },
+        "kasir-api_internal_models.Category": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "tax_inclusive": {
+                    "type": "boolean"
+                },
+                "tax_rate": {
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.CheckoutItem": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.CheckoutRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "idempotency_key": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.CheckoutItem"
+                    }
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.Payment"
+                    }
+                }
+            }
+        },
+        "kasir-api_internal_models.ClosingType": {
+            "type": "string",
+            "enum": [
+                "x",
+                "z"
+            ],
+            "x-enum-varnames": [
+                "ClosingX",
+                "ClosingZ"
+            ]
+        },
+        "kasir-api_internal_models.DayClosing": {
+            "type": "object",
+            "properties": {
+                "business_date": {
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "cash_difference": {
+                    "type": "integer"
+                },
+                "cash_refund": {
+                    "type": "integer"
+                },
+                "cash_sales": {
+                    "type": "integer"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "string"
+                },
+                "counted_cash": {
+                    "type": "integer"
+                },
+                "expected_cash": {
+                    "type": "integer"
+                },
+                "gross_sales": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "opening_cash": {
+                    "type": "integer"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.PaymentSummary"
+                    }
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "refunds": {
+                    "type": "integer"
+                },
+                "total_discount": {
+                    "type": "integer"
+                },
+                "total_refund": {
+                    "type": "integer"
+                },
+                "total_revenue": {
+                    "type": "integer"
+                },
+                "total_sales": {
+                    "type": "integer"
+                },
+                "total_service_charge": {
+                    "type": "integer"
+                },
+                "total_tax": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "integer"
+                },
+                "type": {
+                    "enum": [
+                        "x",
+                        "z"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.ClosingType"
+                        }
+                    ]
+                }
+            }
+        },
+        "kasir-api_internal_models.DayClosingList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.DayClosing"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.DayClosingRequest": {
+            "type": "object",
+            "required": [
+                "counted_cash"
+            ],
+            "properties": {
+                "business_date": {
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "counted_cash": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "opening_cash": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.GoodsReceipt": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.GoodsReceiptItem"
+                    }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "purchase_order_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.GoodsReceiptItem": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "purchase_order_line_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.GoodsReceiptLine": {
+            "type": "object",
+            "properties": {
+                "purchase_order_line_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.GoodsReceiptRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.GoodsReceiptLine"
+                    }
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "kasir-api_internal_models.HeatmapCell": {
+            "type": "object",
+            "properties": {
+                "day_of_week": {
+                    "type": "integer"
+                },
+                "hour": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.OutstandingPurchase": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "outstanding_quantity": {
+                    "type": "integer"
+                },
+                "outstanding_value": {
+                    "type": "integer"
+                },
+                "purchase_order_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrderStatus"
+                }
+            }
+        },
+        "kasir-api_internal_models.OutstandingSupplier": {
+            "type": "object",
+            "properties": {
+                "outstanding_quantity": {
+                    "type": "integer"
+                },
+                "outstanding_value": {
+                    "type": "integer"
+                },
+                "purchase_orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.OutstandingPurchase"
+                    }
+                },
+                "supplier_id": {
+                    "type": "integer"
+                },
+                "supplier_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "kasir-api_internal_models.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "enum": [
+                        "cash",
+                        "qris",
+                        "debit",
+                        "ewallet"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.PaymentMethod"
+                        }
+                    ]
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.PaymentMethod": {
+            "type": "string",
+            "enum": [
+                "cash",
+                "qris",
+                "debit",
+                "ewallet"
+            ],
+            "x-enum-varnames": [
+                "PaymentMethodCash",
+                "PaymentMethodQRIS",
+                "PaymentMethodDebit",
+                "PaymentMethodEWallet"
+            ]
+        },
+        "kasir-api_internal_models.PaymentSummary": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "method": {
+                    "$ref": "#/definitions/kasir-api_internal_models.PaymentMethod"
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.Product": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "category": {
+                    "$ref": "#/definitions/kasir-api_internal_models.Category"
+                },
+                "category_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "cost_price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.ProductOption"
+                    }
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.ProductVariant"
+                    }
+                }
+            }
+        },
+        "kasir-api_internal_models.ProductBestSeller": {
+            "type": "object",
+            "properties": {
+                "nama": {
+                    "type": "string"
+                },
+                "qty_terjual": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.ProductList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.Product"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.ProductOption": {
+            "type": "object",
+            "required": [
+                "name",
+                "values"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "kasir-api_internal_models.ProductVariant": {
+            "type": "object",
+            "required": [
+                "options"
+            ],
+            "properties": {
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "cost_price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.ProfitSummary": {
+            "type": "object",
+            "properties": {
+                "cost_of_goods_sold": {
+                    "type": "integer"
+                },
+                "gross_margin": {
+                    "type": "number"
+                },
+                "gross_profit": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "net_sales": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.Promotion": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "buy_qty": {
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string",
+                    "example": "17:00"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "get_qty": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "min_spend": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string",
+                    "example": "15:00"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "enum": [
+                        "percentage",
+                        "fixed",
+                        "buy_x_get_y",
+                        "min_spend"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.PromotionType"
+                        }
+                    ]
+                }
+            }
+        },
+        "kasir-api_internal_models.PromotionType": {
+            "type": "string",
+            "enum": [
+                "percentage",
+                "fixed",
+                "buy_x_get_y",
+                "min_spend"
+            ],
+            "x-enum-varnames": [
+                "PromotionTypePercentage",
+                "PromotionTypeFixed",
+                "PromotionTypeBuyXGetY",
+                "PromotionTypeMinSpend"
+            ]
+        },
+        "kasir-api_internal_models.PurchaseOrder": {
+            "type": "object",
+            "required": [
+                "lines"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrderLine"
+                    }
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "receipts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.GoodsReceipt"
+                    }
+                },
+                "status": {
+                    "enum": [
+                        "open",
+                        "partially_received",
+                        "received",
+                        "cancelled"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrderStatus"
+                        }
+                    ]
+                },
+                "supplier_id": {
+                    "type": "integer"
+                },
+                "supplier_name": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.PurchaseOrderLine": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "received_quantity": {
+                    "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "variant_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "variant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "kasir-api_internal_models.PurchaseOrderList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.PurchaseOrder"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.PurchaseOrderStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "partially_received",
+                "received",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "PurchaseOrderOpen",
+                "PurchaseOrderPartiallyReceived",
+                "PurchaseOrderReceived",
+                "PurchaseOrderCancelled"
+            ]
+        },
+        "kasir-api_internal_models.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.RefundItem"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "enum": [
+                        "void",
+                        "refund"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.RefundType"
+                        }
+                    ]
+                }
+            }
+        },
+        "kasir-api_internal_models.RefundItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "cost_amount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "refund_id": {
+                    "type": "integer"
+                },
+                "service_charge": {
+                    "type": "integer"
+                },
+                "tax_amount": {
+                    "type": "integer"
+                },
+                "tax_base": {
+                    "type": "integer"
+                },
+                "transaction_detail_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.RefundItemRequest": {
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "type": "integer"
+                },
+                "transaction_detail_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.RefundRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.RefundItemRequest"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "kasir-api_internal_models.RefundType": {
+            "type": "string",
+            "enum": [
+                "void",
+                "refund"
+            ],
+            "x-enum-varnames": [
+                "RefundTypeVoid",
+                "RefundTypeRefund"
+            ]
+        },
+        "kasir-api_internal_models.SalesBreakdown": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.SalesReport": {
+            "type": "object",
+            "properties": {
+                "basket": {
+                    "$ref": "#/definitions/kasir-api_internal_models.BasketSummary"
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.SalesBreakdown"
+                    }
+                },
+                "cost_of_goods_sold": {
+                    "type": "integer"
+                },
+                "gross_margin": {
+                    "type": "number"
+                },
+                "gross_profit": {
+                    "type": "integer"
+                },
+                "gross_sales": {
+                    "type": "integer"
+                },
+                "heatmap": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.HeatmapCell"
+                    }
+                },
+                "net_sales": {
+                    "type": "integer"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.PaymentSummary"
+                    }
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "produk_terlaris": {
+                    "$ref": "#/definitions/kasir-api_internal_models.ProductBestSeller"
+                },
+                "profit_by_category": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.ProfitSummary"
+                    }
+                },
+                "profit_by_product": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.ProfitSummary"
+                    }
+                },
+                "tax_breakdown": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.TaxSummary"
+                    }
+                },
+                "top_products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.SalesBreakdown"
+                    }
+                },
+                "total_discount": {
+                    "type": "integer"
+                },
+                "total_refund": {
+                    "type": "integer"
+                },
+                "total_revenue": {
+                    "type": "integer"
+                },
+                "total_service_charge": {
+                    "type": "integer"
+                },
+                "total_tax": {
+                    "type": "integer"
+                },
+                "total_transaksi": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.StockCount": {
+            "type": "object",
+            "properties": {
+                "counted": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "kasir-api_internal_models.StockCountRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.StockCount"
+                    }
+                }
+            }
+        },
+        "kasir-api_internal_models.StockMovement": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
                 },
-                "name": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "enum": [
+                        "sale",
+                        "refund",
+                        "adjustment",
+                        "purchase",
+                        "transfer"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockMovementReason"
+                        }
+                    ]
+                },
+                "reference_id": {
+                    "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                },
+                "variant_name": {
                     "type": "string"
                 }
             }
         },
-        "kasir-api_internal_models.CheckoutItem": {
+        "kasir-api_internal_models.StockMovementList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.StockMovement"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.StockMovementReason": {
+            "type": "string",
+            "enum": [
+                "sale",
+                "refund",
+                "adjustment",
+                "purchase",
+                "transfer"
+            ],
+            "x-enum-varnames": [
+                "StockMovementSale",
+                "StockMovementRefund",
+                "StockMovementAdjustment",
+                "StockMovementPurchase",
+                "StockMovementTransfer"
+            ]
+        },
+        "kasir-api_internal_models.StockMovementRequest": {
             "type": "object",
             "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                },
                 "product_id": {
                     "type": "integer"
                 },
                 "quantity": {
                     "type": "integer"
+                },
+                "reason": {
+                    "enum": [
+                        "adjustment",
+                        "transfer"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockMovementReason"
+                        }
+                    ]
+                },
+                "reference_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "variant_id": {
+                    "type": "integer",
+                    "minimum": 0
                 }
             }
         },
-        "kasir-api_internal_models.CheckoutRequest": {
+        "kasir-api_internal_models.StockOpname": {
             "type": "object",
             "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
                 "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/kasir-api_internal_models.CheckoutItem"
+                        "$ref": "#/definitions/kasir-api_internal_models.StockOpnameItem"
                     }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "status": {
+                    "enum": [
+                        "open",
+                        "posted",
+                        "cancelled"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/kasir-api_internal_models.StockOpnameStatus"
+                        }
+                    ]
+                },
+                "summary": {
+                    "$ref": "#/definitions/kasir-api_internal_models.StockOpnameSummary"
                 }
             }
         },
-        "kasir-api_internal_models.Product": {
+        "kasir-api_internal_models.StockOpnameItem": {
             "type": "object",
             "properties": {
-                "category": {
-                    "$ref": "#/definitions/kasir-api_internal_models.Category"
+                "counted": {
+                    "type": "integer"
                 },
-                "category_id": {
+                "counted_at": {
+                    "type": "string"
+                },
+                "counted_by": {
+                    "type": "string"
+                },
+                "expected": {
                     "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
                     "type": "string"
                 },
-                "price": {
+                "unit_cost": {
                     "type": "integer"
                 },
-                "stock": {
+                "variance": {
+                    "type": "integer"
+                },
+                "variance_value": {
+                    "type": "integer"
+                },
+                "variant_id": {
                     "type": "integer"
+                },
+                "variant_name": {
+                    "type": "string"
                 }
             }
         },
-        "kasir-api_internal_models.ProductBestSeller": {
+        "kasir-api_internal_models.StockOpnameList": {
             "type": "object",
             "properties": {
-                "nama": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.StockOpname"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.StockOpnameRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "kasir-api_internal_models.StockOpnameStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "posted",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "StockOpnameOpen",
+                "StockOpnamePosted",
+                "StockOpnameCancelled"
+            ]
+        },
+        "kasir-api_internal_models.StockOpnameSummary": {
+            "type": "object",
+            "properties": {
+                "counted": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "integer"
+                },
+                "shortage_value": {
+                    "type": "integer"
+                },
+                "surplus_value": {
+                    "type": "integer"
+                },
+                "variance_quantity": {
+                    "type": "integer"
+                },
+                "variance_value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.StockShortage": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
                     "type": "string"
                 },
-                "qty_terjual": {
+                "requested": {
+                    "type": "integer"
+                },
+                "variant_id": {
                     "type": "integer"
+                },
+                "variant_name": {
+                    "type": "string"
                 }
             }
         },
-        "kasir-api_internal_models.SalesReport": {
+        "kasir-api_internal_models.Supplier": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "produk_terlaris": {
-                    "$ref": "#/definitions/kasir-api_internal_models.ProductBestSeller"
+                "address": {
+                    "type": "string"
                 },
-                "total_revenue": {
+                "contact_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "id": {
                     "type": "integer"
                 },
-                "total_transaksi": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "kasir-api_internal_models.TaxSummary": {
+            "type": "object",
+            "properties": {
+                "inclusive": {
+                    "type": "boolean"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "tax_amount": {
+                    "type": "integer"
+                },
+                "tax_base": {
                     "type": "integer"
                 }
             }
@@ -640,6 +4026,12 @@ const docTemplate = `{
         "kasir-api_internal_models.Transaction": {
             "type": "object",
             "properties": {
+                "cart_promotion": {
+                    "type": "string"
+                },
+                "cart_promotion_id": {
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -649,9 +4041,57 @@ const docTemplate = `{
                         "$ref": "#/definitions/kasir-api_internal_models.TransactionDetail"
                     }
                 },
+                "discount_amount": {
+                    "type": "integer"
+                },
+                "gross_amount": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "idempotency_key": {
+                    "type": "string"
+                },
+                "kembalian": {
+                    "type": "integer"
+                },
+                "paid_amount": {
+                    "type": "integer"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.Payment"
+                    }
+                },
+                "refunded_amount": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.Refund"
+                    }
+                },
+                "service_charge": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "completed",
+                        "partially_refunded",
+                        "refunded",
+                        "voided"
+                    ]
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "tax_amount": {
+                    "type": "integer"
+                },
                 "total_amount": {
                     "type": "integer"
                 }
@@ -660,6 +4100,18 @@ const docTemplate = `{
         "kasir-api_internal_models.TransactionDetail": {
             "type": "object",
             "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "category_name": {
+                    "type": "string"
+                },
+                "cost_amount": {
+                    "type": "integer"
+                },
+                "discount_amount": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -669,14 +4121,75 @@ const docTemplate = `{
                 "product_name": {
                     "type": "string"
                 },
+                "promotion_id": {
+                    "type": "integer"
+                },
+                "promotion_name": {
+                    "type": "string"
+                },
                 "quantity": {
                     "type": "integer"
                 },
+                "service_charge": {
+                    "type": "integer"
+                },
                 "subtotal": {
                     "type": "integer"
                 },
+                "tax_amount": {
+                    "type": "integer"
+                },
+                "tax_base": {
+                    "type": "integer"
+                },
+                "tax_inclusive": {
+                    "type": "boolean"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
                 "transaction_id": {
                     "type": "integer"
+                },
+                "unit_cost": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "integer"
+                },
+                "variant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "kasir-api_internal_models.TransactionList": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/kasir-api_internal_models.Transaction"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "kasir-api_internal_models.VoidRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
                 }
             }
         }
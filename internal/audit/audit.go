@@ -0,0 +1,18 @@
+// Package audit carries who made a request down to the code that records
+// what they changed.
+package audit
+
+import "context"
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx that records user as the one acting.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the user recorded in ctx, or "" when nobody is known.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -1,12 +1,44 @@
 package config
 
 import (
+	"kasir-api/internal/models"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	DBUrl         string `mapstructure:"DATABASE_URL"`
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+
+	// Outlet tax rule in percent (PPN 11 or 12), applied to every category
+	// that has no rule of its own. TaxInclusive means prices already contain it.
+	TaxRate      float64 `mapstructure:"TAX_RATE"`
+	TaxInclusive bool    `mapstructure:"TAX_INCLUSIVE"`
+	// Service charge in percent of the sale before tax, 0 disables it
+	ServiceChargeRate float64 `mapstructure:"SERVICE_CHARGE_RATE"`
+
+	// How a sale is costed for the profit report: "average" for the moving
+	// average cost price, "fifo" for the cost of the oldest stock on hand
+	CostingMethod models.CostingMethod `mapstructure:"COSTING_METHOD"`
+
+	// How long a request may spend on the database before it is cancelled,
+	// as a Go duration ("5s"). Checkout, void and refund share CheckoutTimeout
+	// and reports get ReportTimeout. 0 disables the limit.
+	QueryTimeout    time.Duration `mapstructure:"QUERY_TIMEOUT"`
+	CheckoutTimeout time.Duration `mapstructure:"CHECKOUT_TIMEOUT"`
+	ReportTimeout   time.Duration `mapstructure:"REPORT_TIMEOUT"`
+
+	// The store's time zone (IANA name, not Local) and the HH:MM past midnight
+	// its business day starts. Reports and "today" follow it, a 04:00 cutoff
+	// keeps a late night sale in the day before.
+	Timezone          string `mapstructure:"TIMEZONE"`
+	BusinessDayCutoff string `mapstructure:"BUSINESS_DAY_CUTOFF"`
+
+	// Printed at the top of exported reports
+	StoreName    string `mapstructure:"STORE_NAME"`
+	StoreAddress string `mapstructure:"STORE_ADDRESS"`
+	StorePhone   string `mapstructure:"STORE_PHONE"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -14,6 +46,21 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName("app")
 	viper.SetConfigType("env")
 
+	// Defaults also register the keys, so Unmarshal picks them up from the
+	// environment when they are missing from app.env
+	viper.SetDefault("TAX_RATE", 0)
+	viper.SetDefault("TAX_INCLUSIVE", false)
+	viper.SetDefault("SERVICE_CHARGE_RATE", 0)
+	viper.SetDefault("COSTING_METHOD", models.CostingAverage)
+	viper.SetDefault("QUERY_TIMEOUT", 5*time.Second)
+	viper.SetDefault("CHECKOUT_TIMEOUT", 10*time.Second)
+	viper.SetDefault("REPORT_TIMEOUT", 30*time.Second)
+	viper.SetDefault("TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("BUSINESS_DAY_CUTOFF", "00:00")
+	viper.SetDefault("STORE_NAME", "Kasir")
+	viper.SetDefault("STORE_ADDRESS", "")
+	viper.SetDefault("STORE_PHONE", "")
+
 	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w      *csv.Writer
+	tables int
+	record []string
+}
+
+func newCSV(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+// Table writes the column row. Every table after the first is preceded by a
+// blank line and its title, so a single table stays a plain CSV file.
+func (c *csvWriter) Table(title string, columns ...string) error {
+	if c.tables > 0 {
+		if err := c.w.Write(nil); err != nil {
+			return err
+		}
+		if err := c.w.Write([]string{title}); err != nil {
+			return err
+		}
+	}
+	c.tables++
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) Row(cells ...interface{}) error {
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		c.record = append(c.record, text(cell))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,200 @@
+// Package export writes tabular data as CSV, XLSX or PDF files. Rows are
+// written out as they come, so an export of any size streams instead of
+// being built in memory first. The formats are produced with the standard
+// library only.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	PDF  Format = "pdf"
+)
+
+func (f Format) Valid() bool {
+	switch f {
+	case CSV, XLSX, PDF:
+		return true
+	}
+	return false
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Filename is name with the extension of the format.
+func (f Format) Filename(name string) string {
+	return name + "." + string(f)
+}
+
+// Header describes the document. The PDF prints it above the first page and
+// in the page footers; the spreadsheets only use it for metadata.
+type Header struct {
+	Store   string
+	Address string
+	Phone   string
+	Title   string
+	Period  string
+	Printed time.Time
+	// Location the times in the cells and Printed are shown in, the server's
+	// own when nil
+	Location *time.Location
+	// Landscape turns the PDF pages, for tables with many columns
+	Landscape bool
+}
+
+// Writer writes one or more tables. Table starts a table, CSV separates
+// tables with a blank line and a title row, XLSX gives each its own sheet and
+// the PDF prints the title above it. Row cells may be strings, integers,
+// floats, bools or time.Time; numbers stay numbers in XLSX and are right
+// aligned in the PDF. Close finishes the file and must be called, it does not
+// close the underlying io.Writer.
+type Writer interface {
+	Table(title string, columns ...string) error
+	Row(cells ...interface{}) error
+	Close() error
+}
+
+// New returns a Writer for format writing to w.
+func New(w io.Writer, format Format, header Header) (Writer, error) {
+	if header.Location == nil {
+		header.Location = time.Local
+	}
+	if header.Printed.IsZero() {
+		header.Printed = time.Now()
+	}
+	header.Printed = header.Printed.In(header.Location)
+
+	var ew Writer
+	switch format {
+	case CSV:
+		ew = newCSV(w)
+	case XLSX:
+		ew = newXLSX(w, header)
+	case PDF:
+		ew = newPDF(w, header)
+	default:
+		return nil, fmt.Errorf("export: unknown format %q", format)
+	}
+	return localWriter{Writer: ew, location: header.Location}, nil
+}
+
+// localWriter moves the times of a row into the header's location before
+// they are written.
+type localWriter struct {
+	Writer
+	location *time.Location
+}
+
+func (l localWriter) Row(cells ...interface{}) error {
+	local := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		if t, ok := cell.(time.Time); ok {
+			cell = t.In(l.location)
+		}
+		local[i] = cell
+	}
+	return l.Writer.Row(local...)
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// text is the plain text of a cell, as CSV writes it.
+func text(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(timeLayout)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(cell)
+}
+
+// display is a cell as printed for a reader: numbers with thousands
+// separators and at most two decimals, Indonesian style (1.234.567,5).
+func display(cell interface{}) string {
+	switch v := cell.(type) {
+	case int:
+		return group(strconv.Itoa(v))
+	case int64:
+		return group(strconv.FormatInt(v, 10))
+	case float64:
+		s := strconv.FormatFloat(v, 'f', 2, 64)
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		whole, frac, _ := strings.Cut(s, ".")
+		if frac != "" {
+			return group(whole) + "," + frac
+		}
+		return group(whole)
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case time.Time:
+		return v.Format("2006-01-02 15:04")
+	}
+	return text(cell)
+}
+
+// group puts a dot between every three digits of an integer.
+func group(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > len(sign) {
+			b.WriteByte('.')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func numeric(cell interface{}) bool {
+	switch cell.(type) {
+	case int, int64, float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDisplay(t *testing.T) {
+	tests := []struct {
+		cell interface{}
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.000"},
+		{-1234567, "-1.234.567"},
+		{int64(100000), "100.000"},
+		{1234.5, "1.234,5"},
+		{12.345, "12,35"},
+		{-0.5, "-0,5"},
+		{2.0, "2"},
+		{true, "Yes"},
+		{false, "No"},
+		{"as is", "as is"},
+		{nil, ""},
+		{time.Date(2026, 10, 18, 14, 30, 59, 0, time.UTC), "2026-10-18 14:30"},
+	}
+	for _, tt := range tests {
+		if got := display(tt.cell); got != tt.want {
+			t.Errorf("display(%#v) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(&buf, CSV, Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Table("Sales", "Product", "Qty", "Sold at")
+	w.Row(`Kopi "Susu", L`, 1234, time.Date(2026, 10, 18, 14, 30, 0, 0, time.Local))
+	w.Table("Payments", "Method", "Amount")
+	w.Row("cash", 12.5)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Product,Qty,Sold at\n" +
+		"\"Kopi \"\"Susu\"\", L\",1234,2026-10-18 14:30:00\n" +
+		"\n" +
+		"Payments\n" +
+		"Method,Amount\n" +
+		"cash,12.5\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Format("ods"), Header{}); err == nil {
+		t.Error("no error for an unknown format")
+	}
+	if Format("ods").Valid() || !XLSX.Valid() {
+		t.Error("Valid does not match the formats")
+	}
+}
+
+func TestTimesInHeaderLocation(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	var buf bytes.Buffer
+	w, err := New(&buf, CSV, Header{Location: wib})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Table("Sales", "Sold at")
+	w.Row(time.Date(2026, 10, 17, 20, 30, 0, 0, time.UTC))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Sold at\n2026-10-18 03:30:00\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,428 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points, and the page layout
+const (
+	a4Width    = 595.28
+	a4Height   = 841.89
+	pageMargin = 40.0
+	cellPad    = 3.0
+	rowHeight  = 13.0
+	rowFont    = 8.0
+)
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// Objects with a fixed number; pages are numbered from firstPageObject on as
+// they are written.
+const (
+	catalogObject = iota + 1
+	pagesObject
+	regularFontObject
+	boldFontObject
+	infoObject
+	firstPageObject
+)
+
+// pdfWriter lays tables out on A4 pages with the standard Helvetica fonts, so
+// nothing has to be embedded. Each page is written out as soon as it is full;
+// only the page tree and the cross-reference table wait for Close.
+type pdfWriter struct {
+	out     *countingWriter
+	buf     *bufio.Writer
+	header  Header
+	width   float64
+	height  float64
+	offsets []int64
+	pages   []int
+
+	page *bytes.Buffer
+	y    float64
+
+	columns []string
+	widths  []float64
+	right   []bool // numeric columns, their headings are right aligned too
+	pending bool   // the column headings are not drawn yet
+	err     error
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func newPDF(w io.Writer, header Header) *pdfWriter {
+	buf := bufio.NewWriter(w)
+	p := &pdfWriter{
+		out:     &countingWriter{w: buf},
+		buf:     buf,
+		header:  header,
+		width:   a4Width,
+		height:  a4Height,
+		offsets: make([]int64, firstPageObject),
+	}
+	if header.Landscape {
+		p.width, p.height = a4Height, a4Width
+	}
+
+	p.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.object(regularFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	p.object(boldFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	p.object(infoObject, fmt.Sprintf("<< /Title %s /Author %s /Producer (kasir-api) /CreationDate (D:%s) >>",
+		pdfString(strings.TrimSpace(header.Title+" "+header.Period)), pdfString(header.Store), header.Printed.Format("20060102150405")))
+	return p
+}
+
+func (p *pdfWriter) Table(title string, columns ...string) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.flushHeadings()
+	if p.page == nil {
+		p.newPage()
+	} else {
+		p.y -= 10
+	}
+
+	// Keep the title with the headings and the first row
+	if p.y-18-2*rowHeight < p.bottom() {
+		p.endPage()
+		p.newPage()
+	}
+	p.text(fontBold, 11, pageMargin, p.y-11, title)
+	p.y -= 18
+
+	p.columns = columns
+	p.widths = nil
+	p.pending = true
+	return p.err
+}
+
+func (p *pdfWriter) Row(cells ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.columns == nil {
+		return errors.New("export: Row called before Table")
+	}
+	if p.widths == nil {
+		p.widths = p.columnWidths(cells)
+	}
+	p.flushHeadings()
+	if p.y-rowHeight < p.bottom() {
+		p.endPage()
+		p.newPage()
+		p.headings()
+	}
+
+	x := pageMargin
+	for i, w := range p.widths {
+		if i < len(cells) && cells[i] != nil {
+			s := fit(display(cells[i]), fontRegular, rowFont, w-2*cellPad)
+			tx := x + cellPad
+			if numeric(cells[i]) {
+				tx = x + w - cellPad - textWidth(s, fontRegular, rowFont)
+			}
+			p.text(fontRegular, rowFont, tx, p.y-rowHeight+4, s)
+		}
+		x += w
+	}
+	p.y -= rowHeight
+	fmt.Fprintf(p.page, "0.85 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", pageMargin, p.y, p.width-pageMargin, p.y)
+	return p.err
+}
+
+func (p *pdfWriter) Close() error {
+	if p.err != nil {
+		return p.err
+	}
+	p.flushHeadings()
+	if p.page == nil {
+		p.newPage()
+	}
+	p.endPage()
+
+	kids := make([]string, len(p.pages))
+	for i, n := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", n)
+	}
+	p.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	p.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+
+	xref := p.out.n
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets))
+	for _, offset := range p.offsets[1:] {
+		p.printf("%010d 00000 n \n", offset)
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets), catalogObject, infoObject, xref)
+	if p.err != nil {
+		return p.err
+	}
+	return p.buf.Flush()
+}
+
+func (p *pdfWriter) bottom() float64 {
+	return pageMargin + 10
+}
+
+// newPage starts a page with the store header on the first page and a one
+// line running header on the others.
+func (p *pdfWriter) newPage() {
+	p.page = new(bytes.Buffer)
+	p.y = p.height - pageMargin
+	h := p.header
+
+	if len(p.pages) > 0 {
+		p.text(fontRegular, 8, pageMargin, p.y-8, strings.TrimSpace(h.Store+"  -  "+h.Title+"  "+h.Period))
+		p.y -= 12
+		p.rule(0.5)
+		p.y -= 8
+		return
+	}
+
+	if h.Store != "" {
+		p.text(fontBold, 16, pageMargin, p.y-16, h.Store)
+		p.y -= 22
+	}
+	for _, line := range []string{h.Address, h.Phone} {
+		if line != "" {
+			p.text(fontRegular, 9, pageMargin, p.y-9, line)
+			p.y -= 12
+		}
+	}
+	p.y -= 4
+	p.rule(1)
+	p.y -= 18
+	if h.Title != "" {
+		p.text(fontBold, 13, pageMargin, p.y, h.Title)
+		p.y -= 15
+	}
+	if h.Period != "" {
+		p.text(fontRegular, 10, pageMargin, p.y, h.Period)
+		p.y -= 12
+	}
+	p.y -= 8
+}
+
+// endPage adds the footer and writes the page out.
+func (p *pdfWriter) endPage() {
+	footer := "Printed " + p.header.Printed.Format("2006-01-02 15:04")
+	p.text(fontRegular, 7, pageMargin, pageMargin-16, footer)
+	number := fmt.Sprintf("Page %d", len(p.pages)+1)
+	p.text(fontRegular, 7, p.width-pageMargin-textWidth(number, fontRegular, 7), pageMargin-16, number)
+
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	zw.Write(p.page.Bytes())
+	zw.Close()
+
+	contents := len(p.offsets)
+	p.offsets = append(p.offsets, 0, 0)
+	p.printObject(contents, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n", content.Len()))
+	p.write(content.Bytes())
+	p.printf("\nendstream\nendobj\n")
+
+	pageObject := contents + 1
+	p.object(pageObject, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /%s %d 0 R /%s %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, p.width, p.height, fontRegular, regularFontObject, fontBold, boldFontObject, contents))
+	p.pages = append(p.pages, pageObject)
+	p.page = nil
+}
+
+// columnWidths spreads the page width over the columns in proportion to the
+// headings and the first row. Text columns get some room to spare, later rows
+// may hold longer names.
+func (p *pdfWriter) columnWidths(first []interface{}) []float64 {
+	widths := make([]float64, len(p.columns))
+	p.right = make([]bool, len(p.columns))
+	total := 0.0
+	for i, c := range p.columns {
+		w := textWidth(c, fontBold, rowFont)
+		if i < len(first) && first[i] != nil {
+			p.right[i] = numeric(first[i])
+			w = max(w, textWidth(display(first[i]), fontRegular, rowFont))
+			if _, isText := first[i].(string); isText {
+				w = max(w*1.5, 80)
+			}
+		}
+		widths[i] = w + 2*cellPad
+		total += widths[i]
+	}
+
+	scale := (p.width - 2*pageMargin) / total
+	for i := range widths {
+		widths[i] *= scale
+	}
+	return widths
+}
+
+// flushHeadings draws the headings of a table that had no rows to size its
+// columns by, or that had none at all.
+func (p *pdfWriter) flushHeadings() {
+	if !p.pending {
+		return
+	}
+	if p.widths == nil {
+		p.widths = p.columnWidths(nil)
+	}
+	p.pending = false
+	p.headings()
+}
+
+func (p *pdfWriter) headings() {
+	fmt.Fprintf(p.page, "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", pageMargin, p.y-rowHeight, p.width-2*pageMargin, rowHeight)
+	x := pageMargin
+	for i, w := range p.widths {
+		s := fit(p.columns[i], fontBold, rowFont, w-2*cellPad)
+		tx := x + cellPad
+		if p.right[i] {
+			tx = x + w - cellPad - textWidth(s, fontBold, rowFont)
+		}
+		p.text(fontBold, rowFont, tx, p.y-rowHeight+4, s)
+		x += w
+	}
+	p.y -= rowHeight
+}
+
+func (p *pdfWriter) rule(width float64) {
+	fmt.Fprintf(p.page, "%.1f w %.2f %.2f m %.2f %.2f l S\n", width, pageMargin, p.y, p.width-pageMargin, p.y)
+}
+
+func (p *pdfWriter) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(p.page, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+func (p *pdfWriter) object(n int, body string) {
+	p.printObject(n, body)
+	p.printf("\nendobj\n")
+}
+
+// printObject records the offset of object n and opens it with body.
+func (p *pdfWriter) printObject(n int, body string) {
+	p.offsets[n] = p.out.n
+	p.printf("%d 0 obj\n%s", n, body)
+}
+
+func (p *pdfWriter) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.out, format, args...)
+	}
+}
+
+func (p *pdfWriter) write(b []byte) {
+	if p.err == nil {
+		_, p.err = p.out.Write(b)
+	}
+}
+
+// fit shortens s with "..." until it is at most width wide.
+func fit(s, font string, size, width float64) string {
+	if textWidth(s, font, size) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && textWidth(string(r)+"...", font, size) > width {
+		r = r[:len(r)-1]
+	}
+	if len(r) == 0 {
+		return ""
+	}
+	return string(r) + "..."
+}
+
+func textWidth(s, font string, size float64) float64 {
+	widths := &helvetica
+	if font == fontBold {
+		widths = &helveticaBold
+	}
+	total := 0
+	for _, c := range winAnsi(s) {
+		if c >= 32 && c < 127 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfString is s as a PDF literal string in WinAnsiEncoding.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range winAnsi(s) {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// winAnsiExtra are the characters WinAnsiEncoding places in 0x80-0x9f.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// winAnsi encodes s for the standard fonts, characters they lack become "?".
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			out = append(out, byte(r))
+		case winAnsiExtra[r] != 0:
+			out = append(out, winAnsiExtra[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// Glyph widths of the printable ASCII characters (32-126) in thousandths of
+// the font size, from the Adobe font metrics.
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePDF writes rows rows of a single table.
+func writePDF(t *testing.T, header Header, rows int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := New(&buf, PDF, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Table("Transactions", "ID", "Product", "Total"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= rows; i++ {
+		if err := w.Row(i, fmt.Sprintf("Kopi Susu (%d)", i), 1234567); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+
+// checkXref checks that startxref points at the cross-reference table and
+// every entry in it at its object, and returns the number of objects.
+func checkXref(t *testing.T, pdf []byte) int {
+	t.Helper()
+	m := startxrefPattern.FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref >= len(pdf) || !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil || first != 0 {
+		t.Fatalf("xref subsection %q", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("entry 0 is %q", lines[2])
+	}
+	for n := 1; n < count; n++ {
+		entry := lines[2+n]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("entry %d is %q", n, entry)
+		}
+		offset, err := strconv.Atoi(entry[:10])
+		if err != nil {
+			t.Fatalf("entry %d is %q", n, entry)
+		}
+		if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("entry %d points at %q, want %q", n, pdf[offset:min(offset+12, len(pdf))], want)
+		}
+	}
+	if lines[2+count] != "trailer" || !strings.Contains(lines[3+count], fmt.Sprintf("/Size %d ", count)) {
+		t.Errorf("trailer %q %q does not give size %d", lines[2+count], lines[3+count], count)
+	}
+	return count
+}
+
+var streamPattern = regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+
+// pageContents inflates every content stream, checking its length.
+func pageContents(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	var pages []string
+	for _, loc := range streamPattern.FindAllSubmatchIndex(pdf, -1) {
+		length, _ := strconv.Atoi(string(pdf[loc[2]:loc[3]]))
+		data := pdf[loc[1] : loc[1]+length]
+		if !bytes.HasPrefix(pdf[loc[1]+length:], []byte("\nendstream\nendobj\n")) {
+			t.Fatalf("stream at %d does not end after /Length %d", loc[1], length)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func TestPDFCrossReference(t *testing.T) {
+	header := Header{Store: "Toko Maju", Title: "Sales", Printed: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)}
+	for _, rows := range []int{0, 1, 200} {
+		t.Run(strconv.Itoa(rows)+" rows", func(t *testing.T) {
+			pdf := writePDF(t, header, rows)
+			if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+				t.Fatal("no PDF header")
+			}
+			objects := checkXref(t, pdf)
+
+			pages := pageContents(t, pdf)
+			// Fonts, info, catalog and page tree, and a page and its content
+			// stream per page
+			if want := firstPageObject + 2*len(pages); objects != want {
+				t.Errorf("%d objects for %d pages, want %d", objects, len(pages), want)
+			}
+			if count := fmt.Sprintf("/Count %d >>", len(pages)); !bytes.Contains(pdf, []byte(count)) {
+				t.Errorf("page tree does not count %d pages", len(pages))
+			}
+		})
+	}
+}
+
+func TestPDFPages(t *testing.T) {
+	pages := pageContents(t, writePDF(t, Header{Title: "Sales", Printed: time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)}, 200))
+	if len(pages) < 3 {
+		t.Fatalf("200 rows on %d pages", len(pages))
+	}
+	all := strings.Join(pages, "")
+	for _, want := range []string{
+		`(Kopi Susu \(1\))`, `(Kopi Susu \(200\))`, // parentheses escaped
+		`(1.234.567)`, // numbers grouped
+		`(Printed 2026-10-18 09:00)`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("no %s in the pages", want)
+		}
+	}
+	for i, page := range pages {
+		if want := fmt.Sprintf("(Page %d)", i+1); !strings.Contains(page, want) {
+			t.Errorf("page %d has no %s", i+1, want)
+		}
+		// The headings repeat on every page
+		if !strings.Contains(page, "(Product)") {
+			t.Errorf("page %d has no headings", i+1)
+		}
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := map[string]string{
+		"plain":          "(plain)",
+		`a (b) \c`:       `(a \(b\) \\c)`,
+		"tab\tnew\nline": "(tab new line)",
+		"Café – 5€":      "(Caf\xe9 \x96 5\x80)",
+		"emoji ☕ and 中文": "(emoji ? and ??)",
+	}
+	for in, want := range tests {
+		if got := pdfString(in); got != want {
+			t.Errorf("pdfString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPDFInfoEscaped(t *testing.T) {
+	pdf := writePDF(t, Header{Store: `Toko (Maju) \ Jaya`, Title: "Sales", Printed: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)}, 1)
+	if !bytes.Contains(pdf, []byte(`/Author (Toko \(Maju\) \\ Jaya)`)) {
+		t.Error("store not escaped in the document info")
+	}
+	checkXref(t, pdf)
+}
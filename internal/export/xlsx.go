@@ -0,0 +1,268 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cell styles, indexes into cellXfs of xlsxStyles
+const (
+	styleDefault = iota
+	styleHeader
+	styleDateTime
+	styleInteger
+	styleDecimal
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+// xlsxWriter streams a workbook with one worksheet per table. Worksheets are
+// written as zip entries one after the other; the workbook parts that list
+// them are written by Close.
+type xlsxWriter struct {
+	buf    *bufio.Writer
+	zip    *zip.Writer
+	header Header
+	sheet  io.Writer
+	sheets []string
+	row    int
+}
+
+func newXLSX(w io.Writer, header Header) *xlsxWriter {
+	buf := bufio.NewWriter(w)
+	return &xlsxWriter{buf: buf, zip: zip.NewWriter(buf), header: header}
+}
+
+func (x *xlsxWriter) Table(title string, columns ...string) error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	x.sheets = append(x.sheets, sheetName(title, x.sheets))
+	sheet, err := x.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if err != nil {
+		return err
+	}
+	x.sheet = sheet
+	x.row = 0
+
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`); err != nil {
+		return err
+	}
+	if len(columns) > 0 {
+		if _, err := fmt.Fprintf(sheet, `<cols><col min="1" max="%d" width="18" customWidth="1"/></cols>`, len(columns)); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(sheet, "<sheetData>"); err != nil {
+		return err
+	}
+
+	cells := make([]interface{}, len(columns))
+	for i, c := range columns {
+		cells[i] = c
+	}
+	return x.writeRow(cells, styleHeader)
+}
+
+func (x *xlsxWriter) Row(cells ...interface{}) error {
+	if x.sheet == nil {
+		return errors.New("export: Row called before Table")
+	}
+	return x.writeRow(cells, styleDefault)
+}
+
+func (x *xlsxWriter) writeRow(cells []interface{}, style int) error {
+	x.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case int:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, styleInteger, v)
+		case int64:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, styleInteger, v)
+		case float64:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDecimal, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			n := 0
+			if v {
+				n = 1
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="b"><v>%d</v></c>`, ref, n)
+		case time.Time:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDateTime, strconv.FormatFloat(excelTime(v), 'f', -1, 64))
+		default:
+			fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(&b, []byte(text(cell)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString("</row>")
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxWriter) endSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	_, err := io.WriteString(x.sheet, "</sheetData></worksheet>")
+	x.sheet = nil
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	// A workbook needs at least one sheet
+	if len(x.sheets) == 0 {
+		if err := x.Table(x.header.Title); err != nil {
+			return err
+		}
+	}
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	var types, sheets, rels strings.Builder
+	for i, name := range x.sheets {
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeText(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(x.sheets)+1)
+
+	parts := []struct {
+		name, content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+` + types.String() + `</Types>`},
+		{"_rels/.rels", xlsxRootRels},
+		{"docProps/core.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+			`<dc:title>` + escapeText(strings.TrimSpace(x.header.Title+" "+x.header.Period)) + `</dc:title>` +
+			`<dc:creator>` + escapeText(x.header.Store) + `</dc:creator>` +
+			`<dcterms:created xsi:type="dcterms:W3CDTF">` + x.header.Printed.UTC().Format(time.RFC3339) + `</dcterms:created>` +
+			`</cp:coreProperties>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := x.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+
+	if err := x.zip.Close(); err != nil {
+		return err
+	}
+	return x.buf.Flush()
+}
+
+// sheetName makes title a valid sheet name that is not in use yet: at most 31
+// characters and none of []:*?/\.
+func sheetName(title string, used []string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "Sheet"
+	}
+	name = truncateRunes(name, 31)
+
+	candidate := name
+	for n := 2; contains(used, candidate); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncateRunes(name, 31-len(suffix)) + suffix
+	}
+	return candidate
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// columnName is the letter of column i counted from 0: A, B, ..., Z, AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// excelTime is t as a spreadsheet serial date, days since 1899-12-30 in t's
+// own time zone.
+func excelTime(t time.Time) float64 {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return local.Sub(epoch).Hours() / 24
+}
+
+// escapeText escapes s for XML text and attribute values alike.
+func escapeText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
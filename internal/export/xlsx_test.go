@@ -0,0 +1,192 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Style  int    `xml:"s,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// writeXLSX writes the tables and opens the result as a zip archive.
+func writeXLSX(t *testing.T, write func(w Writer)) map[string][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := New(&buf, XLSX, Header{Store: "Toko <Maju> & Jaya", Title: "Sales", Printed: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(w)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = b
+	}
+	return files
+}
+
+func TestXLSXCells(t *testing.T) {
+	sold := time.Date(2026, 10, 18, 14, 30, 0, 0, time.Local)
+	files := writeXLSX(t, func(w Writer) {
+		w.Table("Sales", "Product", "Qty", "Margin", "Paid", "Sold at", "Note")
+		w.Row(`Kopi "Susu" <L> & Roti`, 3, 12.5, true, sold, nil)
+		w.Row("  spaced  ", int64(-1500), 0.0, false, sold, "last")
+	})
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(files["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("sheet1.xml: %v", err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("%d rows, want the headings and 2", len(sheet.Rows))
+	}
+
+	headings := sheet.Rows[0].Cells
+	if len(headings) != 6 || headings[0].Inline != "Product" || headings[5].Inline != "Note" || headings[0].Style != styleHeader {
+		t.Errorf("headings %+v", headings)
+	}
+
+	row := sheet.Rows[1]
+	if row.R != 2 {
+		t.Errorf("second row numbered %d", row.R)
+	}
+	// The nil cell is left out
+	if len(row.Cells) != 5 {
+		t.Fatalf("%d cells, want 5", len(row.Cells))
+	}
+	want := []struct {
+		ref, typ, value string
+		style           int
+	}{
+		{"A2", "inlineStr", `Kopi "Susu" <L> & Roti`, styleDefault},
+		{"B2", "", "3", styleInteger},
+		{"C2", "", "12.5", styleDecimal},
+		{"D2", "b", "1", styleDefault},
+		{"E2", "", "46313.604166666664", styleDateTime},
+	}
+	for i, w := range want {
+		c := row.Cells[i]
+		value := c.Value
+		if c.Type == "inlineStr" {
+			value = c.Inline
+		}
+		if c.Ref != w.ref || c.Type != w.typ || value != w.value || c.Style != w.style {
+			t.Errorf("cell %d: %s t=%q s=%d %q, want %s t=%q s=%d %q", i, c.Ref, c.Type, c.Style, value, w.ref, w.typ, w.style, w.value)
+		}
+	}
+
+	last := sheet.Rows[2].Cells
+	if last[0].Inline != "  spaced  " || last[1].Value != "-1500" || last[3].Value != "0" || last[5].Ref != "F3" {
+		t.Errorf("last row %+v", last)
+	}
+
+	// Text is escaped, not written raw
+	raw := string(files["xl/worksheets/sheet1.xml"])
+	if !strings.Contains(raw, "&lt;L&gt; &amp; Roti") {
+		t.Error("special characters not escaped in sheet1.xml")
+	}
+}
+
+func TestXLSXWorkbook(t *testing.T) {
+	files := writeXLSX(t, func(w Writer) {
+		w.Table("Sales/Refunds", "A")
+		w.Table("Sales Refunds", "A")
+		w.Table("Tax & <Charges>", "A")
+		w.Table("A very long title for a single worksheet", "A")
+	})
+
+	for name, b := range files {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		d := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed: %v", name, err)
+				break
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet4.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s missing", name)
+		}
+	}
+
+	var wb xlsxWorkbook
+	if err := xml.Unmarshal(files["xl/workbook.xml"], &wb); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Sales Refunds", "Sales Refunds (2)", "Tax & <Charges>", "A very long title for a single "}
+	if len(wb.Sheets) != len(want) {
+		t.Fatalf("%d sheets, want %d", len(wb.Sheets), len(want))
+	}
+	for i, name := range want {
+		if wb.Sheets[i].Name != name {
+			t.Errorf("sheet %d named %q, want %q", i+1, wb.Sheets[i].Name, name)
+		}
+	}
+
+	if core := string(files["docProps/core.xml"]); !strings.Contains(core, "<dc:creator>Toko &lt;Maju&gt; &amp; Jaya</dc:creator>") {
+		t.Errorf("store not escaped in core.xml: %s", core)
+	}
+}
+
+func TestXLSXWithoutTables(t *testing.T) {
+	files := writeXLSX(t, func(w Writer) {})
+	var wb xlsxWorkbook
+	if err := xml.Unmarshal(files["xl/workbook.xml"], &wb); err != nil {
+		t.Fatal(err)
+	}
+	if len(wb.Sheets) != 1 || wb.Sheets[0].Name != "Sales" {
+		t.Errorf("sheets %+v, want one named after the title", wb.Sheets)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
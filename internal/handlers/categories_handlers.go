@@ -6,7 +6,6 @@ import (
 	"kasir-api/internal/service"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type CategoryHandler struct {
@@ -17,24 +16,6 @@ func NewCategoryHandler(service service.CategoryService) *CategoryHandler {
 	return &CategoryHandler{service: service}
 }
 
-func (h *CategoryHandler) HandleCategoryList(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		h.GetAll(w, r)
-	} else if r.Method == "POST" {
-		h.Create(w, r)
-	}
-}
-
-func (h *CategoryHandler) HandleCategoryDetail(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		h.GetDetailCategories(w, r)
-	} else if r.Method == "PUT" {
-		h.Update(w, r)
-	} else if r.Method == "DELETE" {
-		h.Delete(w, r)
-	}
-}
-
 // GetAll godoc
 // @Summary Get all categories
 // @Description Get list of all categories
@@ -42,9 +23,14 @@ func (h *CategoryHandler) HandleCategoryDetail(w http.ResponseWriter, r *http.Re
 // @Accept json
 // @Produce json
 // @Success 200 {array} models.Category
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /category [get]
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	category := h.service.GetAll()
+	category, err := h.service.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(category)
 }
@@ -57,17 +43,22 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param category body models.Category true "Category Data"
 // @Success 200 {object} models.Category
-// @Failure 400 {string} string "invalid request"
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED with every violation in details"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /category [post]
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	created, err := h.service.Create(r.Context(), category)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
-	category = h.service.Create(category)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(category)
+	json.NewEncoder(w).Encode(created)
 }
 
 // GetDetailCategories godoc
@@ -78,19 +69,18 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Category ID"
 // @Success 200 {object} models.Category
-// @Failure 400 {string} string "invalid id"
-// @Failure 404 {string} string "category not found"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "CATEGORY_NOT_FOUND"
 // @Router /category/{id} [get]
 func (h *CategoryHandler) GetDetailCategories(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
-	category, err := h.service.GetByID(id)
+	category, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -106,26 +96,25 @@ func (h *CategoryHandler) GetDetailCategories(w http.ResponseWriter, r *http.Req
 // @Param id path int true "Category ID"
 // @Param category body models.Category true "Category Data"
 // @Success 200 {object} models.Category
-// @Failure 400 {string} string "invalid request/id"
-// @Failure 404 {string} string "category not found"
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "CATEGORY_NOT_FOUND"
 // @Router /category/{id} [put]
 func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	updated, err := h.service.Update(id, category)
+	updated, err := h.service.Update(r.Context(), id, category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -141,18 +130,18 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Category ID"
 // @Success 204 {object} nil
-// @Failure 400 {string} string "invalid id"
-// @Failure 404 {string} string "category not found"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "CATEGORY_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "CATEGORY_IN_USE"
 // @Router /category/{id} [delete]
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
-	if err := h.service.Delete(id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/internal/export"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DayClosingHandler struct {
+	service service.DayClosingService
+	store   export.Header
+}
+
+// NewDayClosingHandler returns the handler, store heads the exported reports.
+func NewDayClosingHandler(service service.DayClosingService, store export.Header) *DayClosingHandler {
+	return &DayClosingHandler{service: service, store: store}
+}
+
+// GetDayClosings godoc
+// @Summary List closed business days
+// @Description List the Z reports of the closed business days, latest first. The payments per method are only part of a single Z report.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 20, max 100)"
+// @Success 200 {object} models.DayClosingList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /closing [get]
+func (h *DayClosingHandler) GetDayClosings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var filter models.DayClosingFilter
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				badRequest(w, r, CodeInvalidParameter, "invalid "+p.name)
+				return
+			}
+			*p.dst = n
+		}
+	}
+
+	list, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// CloseDay godoc
+// @Summary Close a business day (Z report)
+// @Description Close the current business day, or an earlier one left open, with the cash counted in the drawer. The day's sales, refunds and payments are totalled and stored with the count as the Z report, which never changes afterwards; from then on no sale, payment or refund can be recorded in the day, checkouts and refunds get BUSINESS_DAY_CLOSED. Sales being recorded while closing are waited for and included.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "User recorded as closing the day"
+// @Param closing body models.DayClosingRequest true "Business day, opening float and counted cash"
+// @Success 201 {object} models.DayClosing
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED, also for a business day that has not started yet"
+// @Failure 409 {object} ErrorResponse "DAY_ALREADY_CLOSED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /closing [post]
+func (h *DayClosingHandler) CloseDay(w http.ResponseWriter, r *http.Request) {
+	var req models.DayClosingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	closing, err := h.service.Close(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(closing)
+}
+
+// GetXReport godoc
+// @Summary Get the X report of a business day
+// @Description Total the current business day, or an earlier one, as it stands without closing it; it can be printed as often as needed and changes as sales come in. With opening_cash and counted_cash it also checks the drawer. With format=csv, xlsx or pdf the report is a file, the PDF a printable summary under the store header.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param date query string false "Business day as YYYY-MM-DD, the current one by default"
+// @Param opening_cash query int false "Cash in the drawer when the day started"
+// @Param counted_cash query int false "Cash counted in the drawer now"
+// @Param format query string false "json (default), csv, xlsx or pdf"
+// @Success 200 {object} models.DayClosing
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED, also for a business day that has not started yet"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /closing/x [get]
+func (h *DayClosingHandler) GetXReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var date time.Time
+	if v := q.Get("date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			badRequest(w, r, CodeInvalidParameter, "invalid date (expected YYYY-MM-DD)")
+			return
+		}
+		date = d
+	}
+	openingCash := 0
+	if v := q.Get("opening_cash"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(w, r, CodeInvalidParameter, "invalid opening_cash")
+			return
+		}
+		openingCash = n
+	}
+	var countedCash *int
+	if v := q.Get("counted_cash"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(w, r, CodeInvalidParameter, "invalid counted_cash")
+			return
+		}
+		countedCash = &n
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	report, err := h.service.XReport(r.Context(), date, openingCash, countedCash)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	h.write(w, r, format, report)
+}
+
+// GetDayClosing godoc
+// @Summary Get the Z report of a closed business day
+// @Description Reprint the Z report stored when the business day was closed, exactly as it was then. With format=csv, xlsx or pdf the report is a file, the PDF a printable summary under the store header.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param date path string true "Business day as YYYY-MM-DD"
+// @Param format query string false "json (default), csv, xlsx or pdf"
+// @Success 200 {object} models.DayClosing
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "DAY_CLOSING_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /closing/{date} [get]
+func (h *DayClosingHandler) GetDayClosing(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse("2006-01-02", r.PathValue("date"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid date (expected YYYY-MM-DD)")
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	closing, err := h.service.GetByDate(r.Context(), date)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	h.write(w, r, format, closing)
+}
+
+// write answers with the report as JSON, or as a file when a format was asked
+// for.
+func (h *DayClosingHandler) write(w http.ResponseWriter, r *http.Request, format export.Format, c *models.DayClosing) {
+	if format == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c)
+		return
+	}
+
+	header := h.store
+	header.Title = strings.ToUpper(string(c.Type)) + " report"
+	if date, err := time.Parse("2006-01-02", c.BusinessDate); err == nil {
+		header.Period = date.Format("Monday, 2 January 2006")
+	}
+	filename := string(c.Type) + "-report-" + c.BusinessDate
+	writeExport(w, r, format, filename, header, func(ew export.Writer) error {
+		return writeDayClosing(ew, c)
+	})
+}
+
+// writeDayClosing lays an X or Z report out as the sales summary, the
+// payments per method and the cash in the drawer.
+func writeDayClosing(ew export.Writer, c *models.DayClosing) error {
+	summary := [][]interface{}{
+		{"Business day", c.BusinessDate},
+		{"From", c.PeriodStart},
+		{"Until", c.PeriodEnd},
+		{"Transactions", c.Transactions},
+		{"Gross sales", c.GrossSales},
+		{"Discount", c.TotalDiscount},
+		{"Tax (net of refunds)", c.TotalTax},
+		{"Service charge (net of refunds)", c.TotalServiceCharge},
+		{"Total sales", c.TotalSales},
+		{"Refunds", c.Refunds},
+		{"Refunded", c.TotalRefund},
+		{"Revenue", c.TotalRevenue},
+	}
+	if c.ClosedAt != nil {
+		summary = append(summary, []interface{}{"Closed at", *c.ClosedAt}, []interface{}{"Closed by", orDash(c.ClosedBy)})
+	}
+	if c.Note != "" {
+		summary = append(summary, []interface{}{"Note", c.Note})
+	}
+	if err := table(ew, "Summary", []string{"Item", "Value"}, summary); err != nil {
+		return err
+	}
+
+	payments := make([][]interface{}, len(c.Payments))
+	for i, p := range c.Payments {
+		payments[i] = []interface{}{string(p.Method), p.Transactions, p.Amount}
+	}
+	if err := table(ew, "Payments", []string{"Method", "Transactions", "Amount"}, payments); err != nil {
+		return err
+	}
+
+	return table(ew, "Cash", []string{"Item", "Value"}, [][]interface{}{
+		{"Opening cash", c.OpeningCash},
+		{"Cash sales", c.CashSales},
+		{"Cash refunds", -c.CashRefund},
+		{"Expected cash", c.ExpectedCash},
+		{"Counted cash", optionalInt(c.CountedCash)},
+		{"Difference", optionalInt(c.CashDifference)},
+	})
+}
+
+// optionalInt puts a dash in the cell of a number that is not known.
+func optionalInt(n *int) interface{} {
+	if n == nil {
+		return "-"
+	}
+	return *n
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/service"
+	"kasir-api/internal/validate"
+	"log"
+	"net/http"
+)
+
+// Error codes are stable, clients can switch on them. Messages are meant for
+// people and may change.
+const (
+	CodeInvalidRequest           = "INVALID_REQUEST"
+	CodeInvalidParameter         = "INVALID_PARAMETER"
+	CodeValidationFailed         = "VALIDATION_FAILED"
+	CodeMethodNotAllowed         = "METHOD_NOT_ALLOWED"
+	CodeNotFound                 = "NOT_FOUND"
+	CodeProductNotFound          = "PRODUCT_NOT_FOUND"
+	CodeCategoryNotFound         = "CATEGORY_NOT_FOUND"
+	CodePromotionNotFound        = "PROMOTION_NOT_FOUND"
+	CodeTransactionNotFound      = "TRANSACTION_NOT_FOUND"
+	CodeStockOpnameNotFound      = "STOCK_OPNAME_NOT_FOUND"
+	CodeSupplierNotFound         = "SUPPLIER_NOT_FOUND"
+	CodePurchaseOrderNotFound    = "PURCHASE_ORDER_NOT_FOUND"
+	CodeDayClosingNotFound       = "DAY_CLOSING_NOT_FOUND"
+	CodeCategoryInUse            = "CATEGORY_IN_USE"
+	CodeDuplicateSKU             = "DUPLICATE_SKU"
+	CodeDuplicateBarcode         = "DUPLICATE_BARCODE"
+	CodeInsufficientStock        = "INSUFFICIENT_STOCK"
+	CodeInsufficientPayment      = "INSUFFICIENT_PAYMENT"
+	CodeIdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeTransactionVoided        = "TRANSACTION_VOIDED"
+	CodeNothingToRefund          = "NOTHING_TO_REFUND"
+	CodeStockOpnameOpen          = "STOCK_OPNAME_OPEN"
+	CodeStockOpnameClosed        = "STOCK_OPNAME_CLOSED"
+	CodeSupplierInUse            = "SUPPLIER_IN_USE"
+	CodePurchaseOrderNotEditable = "PURCHASE_ORDER_NOT_EDITABLE"
+	CodePurchaseOrderClosed      = "PURCHASE_ORDER_CLOSED"
+	CodeDayAlreadyClosed         = "DAY_ALREADY_CLOSED"
+	CodeBusinessDayClosed        = "BUSINESS_DAY_CLOSED"
+	CodeTimeout                  = "TIMEOUT"
+	CodeInternal                 = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes what went wrong. Details points at the offending request
+// fields, Items lists the short products of an INSUFFICIENT_STOCK error.
+type APIError struct {
+	Code      string                 `json:"code" example:"PRODUCT_NOT_FOUND"`
+	Message   string                 `json:"message" example:"product not found"`
+	Details   []FieldError           `json:"details,omitempty"`
+	Items     []models.StockShortage `json:"items,omitempty"`
+	RequestID string                 `json:"request_id,omitempty" example:"3f2b9c1e8a7d4f60"`
+}
+
+type FieldError struct {
+	Field   string `json:"field" example:"category_id"`
+	Message string `json:"message" example:"category_id does not refer to an existing category"`
+}
+
+// errorMappings gives the status and code for the sentinel errors of the
+// service and repository layers. Field is the request field at fault, if any.
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+	field  string
+}{
+	{repository.ErrProductNotFound, http.StatusNotFound, CodeProductNotFound, ""},
+	{repository.ErrCategoryNotFound, http.StatusNotFound, CodeCategoryNotFound, ""},
+	{repository.ErrPromotionNotFound, http.StatusNotFound, CodePromotionNotFound, ""},
+	{repository.ErrTransactionNotFound, http.StatusNotFound, CodeTransactionNotFound, ""},
+	{repository.ErrStockOpnameNotFound, http.StatusNotFound, CodeStockOpnameNotFound, ""},
+	{repository.ErrSupplierNotFound, http.StatusNotFound, CodeSupplierNotFound, ""},
+	{repository.ErrPurchaseOrderNotFound, http.StatusNotFound, CodePurchaseOrderNotFound, ""},
+	{repository.ErrDayClosingNotFound, http.StatusNotFound, CodeDayClosingNotFound, ""},
+
+	{repository.ErrUnknownCategory, http.StatusBadRequest, CodeValidationFailed, "category_id"},
+	{repository.ErrNegativeStock, http.StatusBadRequest, CodeValidationFailed, "stock"},
+	{repository.ErrInvalidProductSort, http.StatusBadRequest, CodeValidationFailed, "sort"},
+	{repository.ErrUnknownVariant, http.StatusBadRequest, CodeValidationFailed, "variants.id"},
+	{repository.ErrStockOpnameItemNotFound, http.StatusBadRequest, CodeValidationFailed, "items"},
+	{repository.ErrDuplicateVariant, http.StatusBadRequest, CodeValidationFailed, "variants.options"},
+	{repository.ErrUnknownPromotionTarget, http.StatusBadRequest, CodeValidationFailed, ""},
+	{service.ErrInvalidPromotion, http.StatusBadRequest, CodeValidationFailed, ""},
+	{service.ErrInvalidPaymentMethod, http.StatusBadRequest, CodeValidationFailed, "payments.method"},
+	{service.ErrInvalidPaymentAmount, http.StatusBadRequest, CodeValidationFailed, "payments.amount"},
+	{service.ErrNonCashOverpayment, http.StatusBadRequest, CodeValidationFailed, "payments.amount"},
+	{service.ErrInsufficientPayment, http.StatusBadRequest, CodeInsufficientPayment, ""},
+	{service.ErrRefundReasonRequired, http.StatusBadRequest, CodeValidationFailed, "reason"},
+	{service.ErrRefundItemsRequired, http.StatusBadRequest, CodeValidationFailed, "items"},
+	{service.ErrInvalidRefundQuantity, http.StatusBadRequest, CodeValidationFailed, "items.quantity"},
+	{repository.ErrDetailNotInTransaction, http.StatusBadRequest, CodeValidationFailed, "items.transaction_detail_id"},
+	{repository.ErrRefundQuantityExceeded, http.StatusBadRequest, CodeValidationFailed, "items.quantity"},
+	{repository.ErrUnknownSupplier, http.StatusBadRequest, CodeValidationFailed, "supplier_id"},
+	{repository.ErrLineNotInPurchaseOrder, http.StatusBadRequest, CodeValidationFailed, "items.purchase_order_line_id"},
+	{repository.ErrReceiptQuantityExceeded, http.StatusBadRequest, CodeValidationFailed, "items.quantity"},
+
+	{repository.ErrCategoryInUse, http.StatusConflict, CodeCategoryInUse, ""},
+	{repository.ErrDuplicateSKU, http.StatusConflict, CodeDuplicateSKU, "sku"},
+	{repository.ErrDuplicateBarcode, http.StatusConflict, CodeDuplicateBarcode, "barcodes"},
+	{repository.ErrInsufficientStock, http.StatusConflict, CodeInsufficientStock, ""},
+	{service.ErrIdempotencyKeyReused, http.StatusConflict, CodeIdempotencyKeyReused, ""},
+	{repository.ErrTransactionVoided, http.StatusConflict, CodeTransactionVoided, ""},
+	{repository.ErrNothingToRefund, http.StatusConflict, CodeNothingToRefund, ""},
+	{repository.ErrStockOpnameAlreadyOpen, http.StatusConflict, CodeStockOpnameOpen, ""},
+	{repository.ErrStockOpnameClosed, http.StatusConflict, CodeStockOpnameClosed, ""},
+	{repository.ErrSupplierInUse, http.StatusConflict, CodeSupplierInUse, ""},
+	{repository.ErrPurchaseOrderNotEditable, http.StatusConflict, CodePurchaseOrderNotEditable, ""},
+	{repository.ErrPurchaseOrderClosed, http.StatusConflict, CodePurchaseOrderClosed, ""},
+	{repository.ErrDayAlreadyClosed, http.StatusConflict, CodeDayAlreadyClosed, "business_date"},
+	{repository.ErrBusinessDayClosed, http.StatusConflict, CodeBusinessDayClosed, ""},
+}
+
+// writeError sends the error envelope with the request ID filled in.
+func writeError(w http.ResponseWriter, r *http.Request, status int, apiErr APIError) {
+	apiErr.RequestID = RequestIDFrom(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr})
+}
+
+func badRequest(w http.ResponseWriter, r *http.Request, code, message string) {
+	writeError(w, r, http.StatusBadRequest, APIError{Code: code, Message: message})
+}
+
+// NotFound answers a path no route matches.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, APIError{Code: CodeNotFound, Message: "not found"})
+}
+
+// MethodNotAllowed answers a path that exists for other methods only. The
+// caller sets the Allow header.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, APIError{Code: CodeMethodNotAllowed, Message: "method not allowed"})
+}
+
+// writeServiceError answers an error returned by a service. Known errors get
+// their mapped status, anything else is logged and reported as a bare 500 so
+// database details do not leak. Queries cut short by the request timeout
+// answer 504, and nothing is written for a client that went away.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrs validate.Errors
+	if errors.As(err, &validationErrs) {
+		details := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			details[i] = FieldError{Field: fe.Field, Message: fe.Message}
+		}
+		writeError(w, r, http.StatusBadRequest, APIError{
+			Code:    CodeValidationFailed,
+			Message: "validation failed",
+			Details: details,
+		})
+		return
+	}
+
+	var stockErr *repository.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		writeError(w, r, http.StatusConflict, APIError{
+			Code:    CodeInsufficientStock,
+			Message: stockErr.Error(),
+			Items:   stockErr.Items,
+		})
+		return
+	}
+
+	for _, m := range errorMappings {
+		if !errors.Is(err, m.err) {
+			continue
+		}
+		apiErr := APIError{Code: m.code, Message: err.Error()}
+		if m.field != "" {
+			apiErr.Details = []FieldError{{Field: m.field, Message: err.Error()}}
+		}
+		writeError(w, r, m.status, apiErr)
+		return
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, r, http.StatusGatewayTimeout, APIError{Code: CodeTimeout, Message: "request timed out"})
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		return
+	default:
+		log.Printf("request %s: %s %s: %v", RequestIDFrom(r.Context()), r.Method, r.URL.Path, err)
+		writeError(w, r, http.StatusInternalServerError, APIError{Code: CodeInternal, Message: "internal server error"})
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/internal/export"
+	"kasir-api/internal/models"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// parseExportFormat reads format=, which is empty for the default JSON answer.
+func parseExportFormat(r *http.Request) (export.Format, error) {
+	v := r.URL.Query().Get("format")
+	if v == "" || v == "json" {
+		return "", nil
+	}
+	if f := export.Format(v); f.Valid() {
+		return f, nil
+	}
+	return "", errors.New("invalid format (expected json, csv, xlsx or pdf)")
+}
+
+// exportResponse sets the file headers on the first write. Until then nothing
+// has gone out, and an error can still be answered in the JSON envelope.
+type exportResponse struct {
+	w        http.ResponseWriter
+	format   export.Format
+	filename string
+	started  bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.format.ContentType())
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.format.Filename(e.filename)))
+	}
+	return e.w.Write(p)
+}
+
+// writeExport streams the file fill writes as an attachment. An error once
+// the file has started can only be logged, the client gets a truncated file.
+func writeExport(w http.ResponseWriter, r *http.Request, format export.Format, filename string, header export.Header, fill func(export.Writer) error) {
+	out := &exportResponse{w: w, format: format, filename: filename}
+	ew, err := export.New(out, format, header)
+	if err == nil {
+		err = fill(ew)
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !out.started {
+		writeServiceError(w, r, err)
+		return
+	}
+	log.Printf("request %s: %s %s: export cut short: %v", RequestIDFrom(r.Context()), r.Method, r.URL.Path, err)
+}
+
+// storeNow is the current time on the store's clock.
+func storeNow(store export.Header) time.Time {
+	if store.Location == nil {
+		return time.Now()
+	}
+	return time.Now().In(store.Location)
+}
+
+// reportPeriod names the business days start to end of a sales report for its
+// title, a whole calendar month reads as the monthly report.
+func reportPeriod(start, end time.Time) (title, period, filename string) {
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+	switch {
+	case from == to:
+		return "Daily sales report", start.Format("Monday, 2 January 2006"), "sales-report-" + from
+	case start.Day() == 1 && start.AddDate(0, 1, -1).Format("2006-01-02") == to:
+		return "Monthly sales report", start.Format("January 2006"), "sales-report-" + start.Format("2006-01")
+	}
+	return "Sales report", from + " to " + to, "sales-report-" + from + "-to-" + to
+}
+
+// writeSalesReport lays the report out as tables: the summary first, then the
+// tax and profit breakdowns and the sections asked for.
+func writeSalesReport(ew export.Writer, report models.SalesReport, opts models.ReportOptions) error {
+	tables := []func() error{
+		func() error {
+			rows := [][]interface{}{
+				{"Gross sales", report.GrossSales},
+				{"Discount", report.TotalDiscount},
+				{"Tax (net of refunds)", report.TotalTax},
+				{"Service charge (net of refunds)", report.TotalServiceCharge},
+				{"Refunds", report.TotalRefund},
+				{"Revenue", report.TotalRevenue},
+				{"Transactions", report.TotalTransaksi},
+				{"Net sales", report.NetSales},
+				{"Cost of goods sold", report.CostOfGoodsSold},
+				{"Gross profit", report.GrossProfit},
+				{"Gross margin (%)", report.GrossMargin},
+				{"Best seller", fmt.Sprintf("%s (%d)", report.ProdukTerlaris.Name, report.ProdukTerlaris.QtyTerjual)},
+			}
+			if report.Basket != nil {
+				rows = append(rows,
+					[]interface{}{"Average basket", report.Basket.AverageBasket},
+					[]interface{}{"Items per transaction", report.Basket.ItemsPerTransaction})
+			}
+			return table(ew, "Summary", []string{"Item", "Value"}, rows)
+		},
+		func() error {
+			rows := make([][]interface{}, len(report.TaxBreakdown))
+			for i, t := range report.TaxBreakdown {
+				rows[i] = []interface{}{t.Rate, t.Inclusive, t.TaxBase, t.TaxAmount}
+			}
+			return table(ew, "Tax", []string{"Rate (%)", "Inclusive", "Tax base (DPP)", "Tax"}, rows)
+		},
+		func() error { return profitTable(ew, "Profit by product", "Product", report.ProfitByProduct) },
+		func() error { return profitTable(ew, "Profit by category", "Category", report.ProfitByCategory) },
+	}
+	if opts.Has(models.ReportTopProducts) {
+		tables = append(tables, func() error { return breakdownTable(ew, "Top products", "Product", report.TopProducts) })
+	}
+	if opts.Has(models.ReportCategories) {
+		tables = append(tables, func() error { return breakdownTable(ew, "Sales by category", "Category", report.Categories) })
+	}
+	if opts.Has(models.ReportPayments) {
+		tables = append(tables, func() error {
+			rows := make([][]interface{}, len(report.Payments))
+			for i, p := range report.Payments {
+				rows[i] = []interface{}{string(p.Method), p.Transactions, p.Amount}
+			}
+			return table(ew, "Payments", []string{"Method", "Transactions", "Amount"}, rows)
+		})
+	}
+	if opts.Has(models.ReportHeatmap) {
+		tables = append(tables, func() error {
+			rows := make([][]interface{}, len(report.Heatmap))
+			for i, c := range report.Heatmap {
+				rows[i] = []interface{}{time.Weekday(c.DayOfWeek).String(), fmt.Sprintf("%02d:00", c.Hour), c.Transactions, c.Revenue}
+			}
+			return table(ew, "Sales by hour", []string{"Day", "Hour", "Transactions", "Revenue"}, rows)
+		})
+	}
+
+	for _, t := range tables {
+		if err := t(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func profitTable(ew export.Writer, title, name string, profits []models.ProfitSummary) error {
+	rows := make([][]interface{}, len(profits))
+	for i, p := range profits {
+		rows[i] = []interface{}{orDash(p.Name), p.Quantity, p.NetSales, p.CostOfGoodsSold, p.GrossProfit, p.GrossMargin}
+	}
+	return table(ew, title, []string{name, "Quantity", "Net sales", "COGS", "Gross profit", "Margin (%)"}, rows)
+}
+
+func breakdownTable(ew export.Writer, title, name string, sales []models.SalesBreakdown) error {
+	rows := make([][]interface{}, len(sales))
+	for i, s := range sales {
+		rows[i] = []interface{}{orDash(s.Name), s.Quantity, s.Revenue}
+	}
+	return table(ew, title, []string{name, "Quantity", "Revenue"}, rows)
+}
+
+func table(ew export.Writer, title string, columns []string, rows [][]interface{}) error {
+	if err := ew.Table(title, columns...); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := ew.Row(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transactionColumns heads the rows of transactionRow.
+var transactionColumns = []string{
+	"ID", "Date", "Status", "Items", "Gross", "Discount", "Subtotal", "Tax", "Service charge",
+	"Total", "Refunded", "Paid", "Change", "Payment",
+}
+
+func transactionRow(t models.Transaction) []interface{} {
+	items := 0
+	for _, d := range t.Details {
+		items += d.Quantity
+	}
+	methods := make([]string, 0, len(t.Payments))
+	for _, p := range t.Payments {
+		methods = append(methods, string(p.Method))
+	}
+	return []interface{}{
+		t.ID, t.CreatedAt, t.Status, items, t.GrossAmount, t.DiscountAmount, t.Subtotal, t.TaxAmount, t.ServiceCharge,
+		t.TotalAmount, t.RefundedAmount, t.PaidAmount, t.Change, strings.Join(methods, ", "),
+	}
+}
+
+// orDash stands in for the empty name of uncategorised sales.
+func orDash(name string) string {
+	if name == "" {
+		return "-"
+	}
+	return name
+}
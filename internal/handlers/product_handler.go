@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"kasir-api/internal/models"
 	"kasir-api/internal/service"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type ProductHandler struct {
@@ -17,59 +17,125 @@ func NewProductHandler(service service.ProductService) *ProductHandler {
 	return &ProductHandler{service: service}
 }
 
-func (h *ProductHandler) HandleProductList(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		h.GetProduct(w, r)
-	} else if r.Method == "POST" {
-		h.CreateProduct(w, r)
-	}
-}
-
-func (h *ProductHandler) HandleProductDetail(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		h.GetDetailProduct(w, r)
-	} else if r.Method == "PUT" {
-		h.UpdateProduct(w, r)
-	} else if r.Method == "DELETE" {
-		h.DeleteProduct(w, r)
-	}
-}
-
 // GetProduct godoc
 // @Summary Get all product
-// @Description Get list of all product
+// @Description Get a page of products with the total count and a link to the next page
 // @Tags product
 // @Accept json
 // @Produce json
 // @Param name query string false "Product Name Filter"
-// @Success 200 {array} models.Product
+// @Param category_id query int false "Only products in this category"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param in_stock query bool false "true for products with stock, false for sold out ones"
+// @Param sort query string false "Comma separated id, name, price or stock, prefix - for descending (default id)"
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 50, max 200)"
+// @Success 200 {object} models.ProductList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown sort key"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /product [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	product := h.service.GetAll(name)
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	list, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if list.Page*list.PerPage < list.Total {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(list.Page+1))
+		q.Set("per_page", strconv.Itoa(list.PerPage))
+		next.RawQuery = q.Encode()
+		list.Next = next.RequestURI()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	json.NewEncoder(w).Encode(list)
+}
+
+func parseProductFilter(r *http.Request) (models.ProductFilter, error) {
+	q := r.URL.Query()
+	filter := models.ProductFilter{
+		Name: q.Get("name"),
+		Sort: q.Get("sort"),
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"category_id", &filter.CategoryID},
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, errors.New("invalid " + p.name)
+			}
+			*p.dst = n
+		}
+	}
+
+	if v := q.Get("min_price"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid min_price")
+		}
+		filter.MinPrice = &n
+	}
+	if v := q.Get("max_price"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid max_price")
+		}
+		filter.MaxPrice = &n
+	}
+	if v := q.Get("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid in_stock")
+		}
+		filter.InStock = &inStock
+	}
+
+	return filter, nil
 }
 
 // CreateProduct godoc
 // @Summary Create new product
-// @Description Create a new product
+// @Description Create a new product, optionally with option types (size, color, flavour) and a variant with its own price, stock and barcodes per combination
 // @Tags product
 // @Accept json
 // @Produce json
 // @Param product body models.Product true "Product Data"
 // @Success 201 {object} models.Product
-// @Failure 400 {string} string "invalid request"
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED with every violation in details"
+// @Failure 409 {object} ErrorResponse "DUPLICATE_SKU or DUPLICATE_BARCODE"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /product [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var productBaru models.Product
 	err := json.NewDecoder(r.Body).Decode(&productBaru)
 	if err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	created := h.service.Create(productBaru)
+	created, err := h.service.Create(r.Context(), productBaru)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated) // 201
 	json.NewEncoder(w).Encode(created)
@@ -83,20 +149,41 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} models.Product
-// @Failure 400 {string} string "invalid id"
-// @Failure 404 {string} string "product not found"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
 // @Router /product/{id} [get]
 func (h *ProductHandler) GetDetailProduct(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/product/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
 
-	p, err := h.service.GetByID(id)
+	p, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// GetProductByBarcode godoc
+// @Summary Find product by barcode
+// @Description Look up the product a scanned EAN-13 or UPC-A barcode belongs to. For a variant barcode, variants holds only the scanned variant.
+// @Tags product
+// @Accept json
+// @Produce json
+// @Param code path string true "EAN-13 or UPC-A barcode"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse "VALIDATION_FAILED for a malformed barcode"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
+// @Router /product/barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	p, err := h.service.GetByBarcode(r.Context(), r.PathValue("code"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -106,34 +193,34 @@ func (h *ProductHandler) GetDetailProduct(w http.ResponseWriter, r *http.Request
 
 // UpdateProduct godoc
 // @Summary Update product
-// @Description Update an existing product
+// @Description Update an existing product. Options and variants are replaced together; variants with an id are updated, those without are added and the ones left out are removed. Stock is not changed, except for the opening stock of new variants; adjust it through POST /api/stock-movements.
 // @Tags product
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Param product body models.Product true "Product Data"
 // @Success 200 {object} models.Product
-// @Failure 400 {string} string "invalid request/id"
-// @Failure 404 {string} string "product not found"
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER, or VALIDATION_FAILED with every violation in details"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "DUPLICATE_SKU or DUPLICATE_BARCODE"
 // @Router /product/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/product/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	var updateData models.Product
 	err = json.NewDecoder(r.Body).Decode(&updateData)
 	if err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	updated, err := h.service.Update(id, updateData)
+	updated, err := h.service.Update(r.Context(), id, updateData)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -149,20 +236,19 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {string} string "invalid id"
-// @Failure 404 {string} string "product not found"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
 // @Router /product/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/product/")
-	id, err := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
 		return
 	}
 
-	err = h.service.Delete(id)
+	err = h.service.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
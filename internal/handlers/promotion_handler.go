@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type PromotionHandler struct {
+	service service.PromotionService
+}
+
+func NewPromotionHandler(service service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all promotions
+// @Description Get list of all promotions, running or not
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Promotion
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /promotion [get]
+func (h *PromotionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.service.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
+
+// Create godoc
+// @Summary Create new promotion
+// @Description Create a percentage, fixed, buy_x_get_y or min_spend promotion
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param promotion body models.Promotion true "Promotion Data"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /promotion [post]
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), promotion)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetDetail godoc
+// @Summary Get detail promotion
+// @Description Get detail of a promotion by ID
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PROMOTION_NOT_FOUND"
+// @Router /promotion/{id} [get]
+func (h *PromotionHandler) GetDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	promotion, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// Update godoc
+// @Summary Update promotion
+// @Description Update an existing promotion
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param promotion body models.Promotion true "Promotion Data"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "PROMOTION_NOT_FOUND"
+// @Router /promotion/{id} [put]
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.service.Update(r.Context(), id, promotion)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete godoc
+// @Summary Delete promotion
+// @Description Delete a promotion by ID. Transactions keep the name of the promotions they used.
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PROMOTION_NOT_FOUND"
+// @Router /promotion/{id} [delete]
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/internal/export"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type PurchaseOrderHandler struct {
+	service service.PurchaseOrderService
+	store   export.Header
+}
+
+// NewPurchaseOrderHandler returns the handler, store is printed at the top of
+// the exported outstanding report.
+func NewPurchaseOrderHandler(service service.PurchaseOrderService, store export.Header) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service, store: store}
+}
+
+// GetPurchaseOrders godoc
+// @Summary List purchase orders
+// @Description List purchase orders newest first, without their lines
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param supplier_id query int false "Only orders of this supplier"
+// @Param status query string false "open, partially_received, received or cancelled"
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 20, max 100)"
+// @Success 200 {object} models.PurchaseOrderList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown status"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.PurchaseOrderFilter{Status: models.PurchaseOrderStatus(q.Get("status"))}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"supplier_id", &filter.SupplierID},
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				badRequest(w, r, CodeInvalidParameter, "invalid "+p.name)
+				return
+			}
+			*p.dst = n
+		}
+	}
+
+	list, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// CreatePurchaseOrder godoc
+// @Summary Create a purchase order
+// @Description Order products from a supplier. Products with variants are ordered per variant. Status, names and received quantities are filled in by the server.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "User recorded on the order"
+// @Param order body models.PurchaseOrder true "Supplier, note and lines"
+// @Success 201 {object} models.PurchaseOrder
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED, also for an unknown supplier or product"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var po models.PurchaseOrder
+	if err := json.NewDecoder(r.Body).Decode(&po); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), po)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetPurchaseOrder godoc
+// @Summary Get a purchase order
+// @Description Get a purchase order with its lines and goods receipts
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PURCHASE_ORDER_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	po, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
+
+// UpdatePurchaseOrder godoc
+// @Summary Update a purchase order
+// @Description Replace the supplier, note and lines of an order nothing has been received against yet
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param order body models.PurchaseOrder true "Supplier, note and lines"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "PURCHASE_ORDER_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "PURCHASE_ORDER_NOT_EDITABLE"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders/{id} [put]
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var po models.PurchaseOrder
+	if err := json.NewDecoder(r.Body).Decode(&po); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.service.Update(r.Context(), id, po)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// CancelPurchaseOrder godoc
+// @Summary Cancel a purchase order
+// @Description Close the order, whatever is still outstanding will not be delivered. Goods already received stay in stock.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "PURCHASE_ORDER_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "PURCHASE_ORDER_CLOSED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	po, err := h.service.Cancel(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary Receive goods against a purchase order
+// @Description Post a goods receipt for some or all of the outstanding quantities. Stock goes up through the stock ledger at the received unit cost, which defaults to the ordered one. The order becomes received once every line is complete.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param X-User-ID header string false "User recorded on the receipt and the stock movements"
+// @Param receipt body models.GoodsReceiptRequest true "Received quantities"
+// @Success 201 {object} models.PurchaseOrder
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED, also for a line of another order or more than is outstanding"
+// @Failure 404 {object} ErrorResponse "PURCHASE_ORDER_NOT_FOUND, or PRODUCT_NOT_FOUND when the product of a line has been deleted"
+// @Failure 409 {object} ErrorResponse "PURCHASE_ORDER_CLOSED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /purchase-orders/{id}/receipts [post]
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var req models.GoodsReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	po, err := h.service.Receive(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(po)
+}
+
+// HandleOutstandingReport godoc
+// @Summary Outstanding purchase orders per supplier
+// @Description What every supplier still has to deliver on its open and partially received orders, in quantity and value at the ordered unit costs. With format=csv, xlsx or pdf it is a file with the totals per supplier and the orders behind them.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param supplier_id query int false "Only this supplier"
+// @Param format query string false "json (default), csv, xlsx or pdf"
+// @Success 200 {array} models.OutstandingSupplier
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "SUPPLIER_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /report/outstanding-po [get]
+func (h *PurchaseOrderHandler) HandleOutstandingReport(w http.ResponseWriter, r *http.Request) {
+	supplierID := 0
+	if v := r.URL.Query().Get("supplier_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(w, r, CodeInvalidParameter, "invalid supplier_id")
+			return
+		}
+		supplierID = n
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	report, err := h.service.Outstanding(r.Context(), supplierID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if format != "" {
+		today := storeNow(h.store).Format("2006-01-02")
+		header := h.store
+		header.Title = "Outstanding purchase orders"
+		header.Period = "as of " + today
+		filename := "outstanding-po-" + today
+		writeExport(w, r, format, filename, header, func(ew export.Writer) error {
+			return writeOutstandingReport(ew, report)
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func writeOutstandingReport(ew export.Writer, report []models.OutstandingSupplier) error {
+	suppliers := make([][]interface{}, len(report))
+	var orders [][]interface{}
+	for i, s := range report {
+		suppliers[i] = []interface{}{s.SupplierName, s.OutstandingQuantity, s.OutstandingValue}
+		for _, po := range s.PurchaseOrders {
+			orders = append(orders, []interface{}{s.SupplierName, po.PurchaseOrderID, string(po.Status), po.CreatedAt, po.OutstandingQuantity, po.OutstandingValue})
+		}
+	}
+
+	if err := table(ew, "Per supplier", []string{"Supplier", "Quantity", "Value"}, suppliers); err != nil {
+		return err
+	}
+	return table(ew, "Purchase orders", []string{"Supplier", "Purchase order", "Status", "Ordered", "Quantity", "Value"}, orders)
+}
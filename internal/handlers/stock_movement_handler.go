@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type StockMovementHandler struct {
+	service service.StockMovementService
+}
+
+func NewStockMovementHandler(service service.StockMovementService) *StockMovementHandler {
+	return &StockMovementHandler{service: service}
+}
+
+// GetStockMovements godoc
+// @Summary List stock movements
+// @Description List the stock ledger newest first: every sale, refund, adjustment, purchase and transfer with the stock left after it
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param product_id query int false "Only movements of this product"
+// @Param variant_id query int false "Only movements of this variant"
+// @Param reason query string false "sale, refund, adjustment, purchase or transfer"
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 50, max 200)"
+// @Success 200 {object} models.StockMovementList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown reason"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-movements [get]
+func (h *StockMovementHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStockMovementFilter(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	list, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func parseStockMovementFilter(r *http.Request) (models.StockMovementFilter, error) {
+	q := r.URL.Query()
+	filter := models.StockMovementFilter{Reason: models.StockMovementReason(q.Get("reason"))}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"product_id", &filter.ProductID},
+		{"variant_id", &filter.VariantID},
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, errors.New("invalid " + p.name)
+			}
+			*p.dst = n
+		}
+	}
+	return filter, nil
+}
+
+// CreateStockMovement godoc
+// @Summary Record a stock movement
+// @Description Adjust the stock of a product, or of one of its variants, or record a transfer. The quantity is signed, negative takes stock out. The user is taken from the X-User-ID header.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "User recorded on the movement"
+// @Param movement body models.StockMovementRequest true "Movement"
+// @Success 201 {object} models.StockMovement
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED with every violation in details"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "INSUFFICIENT_STOCK when the movement would take stock below zero"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-movements [post]
+func (h *StockMovementHandler) CreateStockMovement(w http.ResponseWriter, r *http.Request) {
+	var req models.StockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	movement, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type StockOpnameHandler struct {
+	service service.StockOpnameService
+}
+
+func NewStockOpnameHandler(service service.StockOpnameService) *StockOpnameHandler {
+	return &StockOpnameHandler{service: service}
+}
+
+// GetStockOpnames godoc
+// @Summary List stock opname sessions
+// @Description List stock count sessions newest first with their variance summary
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param status query string false "open, posted or cancelled"
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 20, max 100)"
+// @Success 200 {object} models.StockOpnameList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown status"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames [get]
+func (h *StockOpnameHandler) GetStockOpnames(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.StockOpnameFilter{Status: models.StockOpnameStatus(q.Get("status"))}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				badRequest(w, r, CodeInvalidParameter, "invalid "+p.name)
+				return
+			}
+			*p.dst = n
+		}
+	}
+
+	list, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// OpenStockOpname godoc
+// @Summary Open a stock opname session
+// @Description Start a stock count of the whole catalogue or of one category. The stock of every product, or of every variant for products with variants, is frozen as the expected quantity. Sessions of different categories can be open at the same time; a category, and the whole catalogue, is counted by one session at a time.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string false "User recorded on the session"
+// @Param opname body models.StockOpnameRequest true "Scope and note"
+// @Success 201 {object} models.StockOpname
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED"
+// @Failure 409 {object} ErrorResponse "STOCK_OPNAME_OPEN when an open session counts the same products"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames [post]
+func (h *StockOpnameHandler) OpenStockOpname(w http.ResponseWriter, r *http.Request) {
+	var req models.StockOpnameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	opname, err := h.service.Open(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(opname)
+}
+
+// GetStockOpname godoc
+// @Summary Get a stock opname session
+// @Description Get a stock count session with every line and the variance in quantity and in value at cost price. variance_only=true keeps the counted lines that differ from the expected quantity.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock opname ID"
+// @Param variance_only query bool false "Only lines with a variance"
+// @Success 200 {object} models.StockOpname
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "STOCK_OPNAME_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames/{id} [get]
+func (h *StockOpnameHandler) GetStockOpname(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	varianceOnly := false
+	if v := r.URL.Query().Get("variance_only"); v != "" {
+		varianceOnly, err = strconv.ParseBool(v)
+		if err != nil {
+			badRequest(w, r, CodeInvalidParameter, "invalid variance_only")
+			return
+		}
+	}
+
+	opname, err := h.service.GetByID(r.Context(), id, varianceOnly)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+// CountStockOpname godoc
+// @Summary Record counted quantities
+// @Description Record the counted quantity of products or variants of an open session. Counting a line again replaces the earlier count.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock opname ID"
+// @Param X-User-ID header string false "User recorded on the counted lines"
+// @Param counts body models.StockCountRequest true "Counted quantities"
+// @Success 200 {object} models.StockOpname
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED, also for a product that is not part of the session"
+// @Failure 404 {object} ErrorResponse "STOCK_OPNAME_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "STOCK_OPNAME_CLOSED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames/{id}/counts [put]
+func (h *StockOpnameHandler) CountStockOpname(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var req models.StockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	opname, err := h.service.Count(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+// PostStockOpname godoc
+// @Summary Post a stock opname session
+// @Description Close the session and adjust the stock of every counted line by counted minus expected through the stock ledger. Uncounted lines and every other product field are left alone.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock opname ID"
+// @Param X-User-ID header string false "User recorded on the adjustments"
+// @Success 200 {object} models.StockOpname
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "STOCK_OPNAME_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "STOCK_OPNAME_CLOSED, or INSUFFICIENT_STOCK when more was sold while counting than the count leaves"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames/{id}/post [post]
+func (h *StockOpnameHandler) PostStockOpname(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, h.service.Post)
+}
+
+// CancelStockOpname godoc
+// @Summary Cancel a stock opname session
+// @Description Close the session without touching stock
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock opname ID"
+// @Success 200 {object} models.StockOpname
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "STOCK_OPNAME_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "STOCK_OPNAME_CLOSED"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /stock-opnames/{id}/cancel [post]
+func (h *StockOpnameHandler) CancelStockOpname(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, h.service.Cancel)
+}
+
+func (h *StockOpnameHandler) close(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id int) (*models.StockOpname, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	opname, err := fn(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
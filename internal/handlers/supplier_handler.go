@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/internal/models"
+	"kasir-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type SupplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(service service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all suppliers
+// @Description Get list of all suppliers ordered by name
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Supplier
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /suppliers [get]
+func (h *SupplierHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.service.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppliers)
+}
+
+// Create godoc
+// @Summary Create new supplier
+// @Description Create a new supplier
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param supplier body models.Supplier true "Supplier Data"
+// @Success 201 {object} models.Supplier
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST or VALIDATION_FAILED with every violation in details"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /suppliers [post]
+func (h *SupplierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	created, err := h.service.Create(r.Context(), supplier)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetDetail godoc
+// @Summary Get detail supplier
+// @Description Get detail of a supplier by ID
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} models.Supplier
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "SUPPLIER_NOT_FOUND"
+// @Router /suppliers/{id} [get]
+func (h *SupplierHandler) GetDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+	supplier, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supplier)
+}
+
+// Update godoc
+// @Summary Update supplier
+// @Description Update an existing supplier
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param supplier body models.Supplier true "Supplier Data"
+// @Success 200 {object} models.Supplier
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "SUPPLIER_NOT_FOUND"
+// @Router /suppliers/{id} [put]
+func (h *SupplierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.service.Update(r.Context(), id, supplier)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete godoc
+// @Summary Delete supplier
+// @Description Delete a supplier by ID, only possible while it has no purchase orders
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "SUPPLIER_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "SUPPLIER_IN_USE"
+// @Router /suppliers/{id} [delete]
+func (h *SupplierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithTimeout bounds the request context, and with it every query the
+// request runs, to d. A zero d leaves the context as it is.
+func WithTimeout(d time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	if d <= 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
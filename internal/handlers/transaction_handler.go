@@ -2,47 +2,60 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"kasir-api/internal/export"
 	"kasir-api/internal/models"
 	"kasir-api/internal/service"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type TransactionHandler struct {
 	service service.TransactionService
+	store   export.Header
 }
 
-func NewTransactionHandler(service service.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+// NewTransactionHandler returns the handler, store carries the name, address
+// and phone printed at the top of exported reports.
+func NewTransactionHandler(service service.TransactionService, store export.Header) *TransactionHandler {
+	return &TransactionHandler{service: service, store: store}
 }
 
 // Checkout godoc
 // @Summary Create a new transaction (Checkout)
-// @Description Create a new transaction with multiple items
+// @Description Create a new transaction with multiple items, priced with the promotions running at checkout, taxed with the category or outlet tax rule plus the outlet service charge, and paid with one or more tenders (cash, qris, debit, ewallet). Only cash may exceed the total; the difference is returned as kembalian. Items name the product by product_id or by a scanned barcode, plus variant_id for a product with variants unless the barcode is the variant's own. Retries carrying the same idempotency key return the transaction created by the first attempt.
 // @Tags transaction
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client generated key, may also be sent as idempotency_key in the body"
 // @Param checkout body models.CheckoutRequest true "Checkout Data"
 // @Success 201 {object} models.Transaction
-// @Failure 400 {string} string "Invalid request body"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, VALIDATION_FAILED (empty items, quantities below 1, duplicate products, malformed barcodes) or INSUFFICIENT_PAYMENT"
+// @Failure 404 {object} ErrorResponse "PRODUCT_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "INSUFFICIENT_STOCK with every short item in items, IDEMPOTENCY_KEY_REUSED, or BUSINESS_DAY_CLOSED once the current business day has been closed"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /checkout [post]
 func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+			badRequest(w, r, CodeInvalidRequest, "Idempotency-Key header does not match idempotency_key in body")
+			return
+		}
+		req.IdempotencyKey = key
+	}
+
+	transaction, err := h.service.Checkout(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -51,24 +64,276 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(transaction)
 }
 
+// GetTransactions godoc
+// @Summary List transactions
+// @Description List transactions newest first, with their details and payments. With format=csv, xlsx or pdf every matching transaction is streamed as a file with one row per transaction, page and per_page are ignored.
+// @Tags transaction
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param start_date query string false "First business day (YYYY-MM-DD)"
+// @Param end_date query string false "Last business day, included (YYYY-MM-DD)"
+// @Param min_amount query int false "Minimum total amount"
+// @Param max_amount query int false "Maximum total amount"
+// @Param product_id query int false "Only transactions containing this product"
+// @Param page query int false "Page number, starts at 1"
+// @Param per_page query int false "Items per page (default 20, max 100)"
+// @Param format query string false "json (default), csv, xlsx or pdf"
+// @Success 200 {object} models.TransactionList
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /transactions [get]
+func (h *TransactionHandler) HandleTransactionList(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	if format != "" {
+		header := h.store
+		header.Title = "Transactions"
+		header.Period = transactionPeriod(filter)
+		header.Landscape = true
+		filename := strings.ReplaceAll(strings.TrimSpace("transactions "+header.Period), " ", "-")
+		writeExport(w, r, format, filename, header, func(ew export.Writer) error {
+			if err := ew.Table("Transactions", transactionColumns...); err != nil {
+				return err
+			}
+			return h.service.EachTransaction(r.Context(), filter, func(t models.Transaction) error {
+				return ew.Row(transactionRow(t)...)
+			})
+		})
+		return
+	}
+
+	list, err := h.service.GetTransactions(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetTransaction godoc
+// @Summary Get detail transaction
+// @Description Get a transaction by ID with its details and payments
+// @Tags transaction
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER"
+// @Failure 404 {object} ErrorResponse "TRANSACTION_NOT_FOUND"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /transactions/{id} [get]
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	transaction, err := h.service.GetTransaction(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// VoidTransaction godoc
+// @Summary Void a transaction
+// @Description Cancel a whole transaction. Every quantity not refunded yet goes back into stock; the original transaction is kept unchanged.
+// @Tags transaction
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param void body models.VoidRequest true "Void reason"
+// @Success 201 {object} models.Refund
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "TRANSACTION_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "TRANSACTION_VOIDED, NOTHING_TO_REFUND, or BUSINESS_DAY_CLOSED once the current business day has been closed"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /transactions/{id}/void [post]
+func (h *TransactionHandler) VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var req models.VoidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	refund, err := h.service.VoidTransaction(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+// RefundTransaction godoc
+// @Summary Refund part of a transaction
+// @Description Return selected quantities of transaction details. The quantities go back into stock; the original transaction is kept unchanged.
+// @Tags transaction
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param refund body models.RefundRequest true "Refund Data"
+// @Success 201 {object} models.Refund
+// @Failure 400 {object} ErrorResponse "INVALID_REQUEST, INVALID_PARAMETER or VALIDATION_FAILED"
+// @Failure 404 {object} ErrorResponse "TRANSACTION_NOT_FOUND"
+// @Failure 409 {object} ErrorResponse "TRANSACTION_VOIDED, NOTHING_TO_REFUND, or BUSINESS_DAY_CLOSED once the current business day has been closed"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
+// @Router /transactions/{id}/refund [post]
+func (h *TransactionHandler) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	refund, err := h.service.RefundTransaction(r.Context(), id, req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	q := r.URL.Query()
+	var filter models.TransactionFilter
+
+	if v := q.Get("start_date"); v != "" {
+		startDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, errors.New("invalid start_date format (expected YYYY-MM-DD)")
+		}
+		filter.StartDate = &startDate
+	}
+	if v := q.Get("end_date"); v != "" {
+		endDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, errors.New("invalid end_date format (expected YYYY-MM-DD)")
+		}
+		filter.EndDate = &endDate
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"product_id", &filter.ProductID},
+		{"page", &filter.Page},
+		{"per_page", &filter.PerPage},
+	}
+	for _, p := range ints {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, errors.New("invalid " + p.name)
+			}
+			*p.dst = n
+		}
+	}
+
+	if v := q.Get("min_amount"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid min_amount")
+		}
+		filter.MinAmount = &n
+	}
+	if v := q.Get("max_amount"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid max_amount")
+		}
+		filter.MaxAmount = &n
+	}
+
+	return filter, nil
+}
+
+// transactionPeriod describes the date range of filter, empty when it has
+// none.
+func transactionPeriod(filter models.TransactionFilter) string {
+	switch {
+	case filter.StartDate != nil && filter.EndDate != nil:
+		return filter.StartDate.Format("2006-01-02") + " to " + filter.EndDate.Format("2006-01-02")
+	case filter.StartDate != nil:
+		return "from " + filter.StartDate.Format("2006-01-02")
+	case filter.EndDate != nil:
+		return "until " + filter.EndDate.Format("2006-01-02")
+	}
+	return ""
+}
+
 // GetDailyReport godoc
 // @Summary Get daily sales report
-// @Description Get total revenue, total transactions, and best selling product for today
+// @Description Get total revenue (net of refunds made today), total transactions, best selling product, and COGS, gross profit and margin overall, per product and per category for the business day running now, in the store time zone and from the configured cutoff. More sections can be asked for with include. With format=csv, xlsx or pdf the report is a file with one table per part; the PDF is a printable summary under the store header.
 // @Tags report
 // @Accept json
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param include query string false "Comma separated extra sections: products, categories, heatmap, payments, basket"
+// @Param top query int false "Products listed by the products section (default 10, max 100)"
+// @Param format query string false "json (default), csv, xlsx or pdf"
 // @Success 200 {object} models.SalesReport
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown section"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /report/hari-ini [get]
 func (h *TransactionHandler) HandleDailyReport(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	opts, err := parseReportOptions(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	report, err := h.service.GetDailyReport()
+	report, err := h.service.GetDailyReport(r.Context(), opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if format != "" {
+		h.writeReport(w, r, format, report, opts)
 		return
 	}
 
@@ -78,51 +343,97 @@ func (h *TransactionHandler) HandleDailyReport(w http.ResponseWriter, r *http.Re
 
 // GetReport godoc
 // @Summary Get sales report by date range
-// @Description Get total revenue, total transactions, and best selling product for a specific date range
+// @Description Get total revenue (net of refunds made in the range), total transactions, best selling product, and COGS, gross profit and margin overall, per product and per category for a range of business days, each running from the configured cutoff in the store time zone to the next day's cutoff. More sections can be asked for with include. With format=csv, xlsx or pdf the report is a file with one table per part; the PDF is a printable summary under the store header, titled as the monthly report for a whole calendar month.
 // @Tags report
 // @Accept json
 // @Produce json
-// @Param start_date query string true "Start Date (YYYY-MM-DD)"
-// @Param end_date query string true "End Date (YYYY-MM-DD)"
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param start_date query string true "First business day (YYYY-MM-DD)"
+// @Param end_date query string true "Last business day, included (YYYY-MM-DD)"
+// @Param include query string false "Comma separated extra sections: products, categories, heatmap, payments, basket"
+// @Param top query int false "Products listed by the products section (default 10, max 100)"
+// @Param format query string false "json (default), csv, xlsx or pdf"
 // @Success 200 {object} models.SalesReport
-// @Failure 400 {string} string "Invalid date format"
-// @Failure 500 {string} string "Internal Server Error"
+// @Failure 400 {object} ErrorResponse "INVALID_PARAMETER or VALIDATION_FAILED for an unknown section"
+// @Failure 500 {object} ErrorResponse "INTERNAL_ERROR"
 // @Router /report [get]
 func (h *TransactionHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	startDateStr := r.URL.Query().Get("start_date")
 	endDateStr := r.URL.Query().Get("end_date")
 
 	if startDateStr == "" || endDateStr == "" {
-		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "start_date and end_date are required")
 		return
 	}
 
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		http.Error(w, "Invalid start_date format (expected YYYY-MM-DD)", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid start_date format (expected YYYY-MM-DD)")
 		return
 	}
 
 	endDate, err := time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		http.Error(w, "Invalid end_date format (expected YYYY-MM-DD)", http.StatusBadRequest)
+		badRequest(w, r, CodeInvalidParameter, "invalid end_date format (expected YYYY-MM-DD)")
 		return
 	}
 
-	// Set end date time to 23:59:59
-	endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	opts, err := parseReportOptions(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		badRequest(w, r, CodeInvalidParameter, err.Error())
+		return
+	}
 
-	report, err := h.service.GetReport(startDate, endDate)
+	report, err := h.service.GetReport(r.Context(), startDate, endDate, opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if format != "" {
+		h.writeReport(w, r, format, report, opts)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
+
+// writeReport sends the report as a file titled after its business days.
+func (h *TransactionHandler) writeReport(w http.ResponseWriter, r *http.Request, format export.Format, report models.SalesReport, opts models.ReportOptions) {
+	header := h.store
+	var filename string
+	header.Title, header.Period, filename = reportPeriod(report.PeriodStart, report.PeriodEnd.AddDate(0, 0, -1))
+	writeExport(w, r, format, filename, header, func(ew export.Writer) error {
+		return writeSalesReport(ew, report, opts)
+	})
+}
+
+// parseReportOptions reads the comma separated include list and top, the
+// service checks the section names.
+func parseReportOptions(r *http.Request) (models.ReportOptions, error) {
+	q := r.URL.Query()
+	var opts models.ReportOptions
+
+	for _, section := range strings.Split(q.Get("include"), ",") {
+		if section = strings.TrimSpace(section); section != "" {
+			opts.Include = append(opts.Include, models.ReportSection(section))
+		}
+	}
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New("invalid top")
+		}
+		opts.Top = n
+	}
+
+	return opts, nil
+}
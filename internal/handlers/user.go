@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"kasir-api/internal/audit"
+	"net/http"
+	"strings"
+)
+
+const userHeader = "X-User-ID"
+
+// User records the X-User-ID header, set by the POS or the proxy in front of
+// the API, as the user behind the request. It is trusted as is, there is no
+// authentication in the API itself.
+func User(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := strings.TrimSpace(r.Header.Get(userHeader))
+		if user == "" || len(user) > 64 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithUser(r.Context(), user)))
+	})
+}
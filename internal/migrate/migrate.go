@@ -0,0 +1,294 @@
+// Package migrate applies the versioned SQL files in db/migrations.
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql and
+// the applied version is kept in schema_migrations using the same layout as
+// golang-migrate, so the migrate CLI can still be pointed at the database.
+// Every migration runs in its own transaction, and a Postgres advisory lock
+// keeps two instances starting at the same time from migrating concurrently.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockKey identifies the migration advisory lock, any constant unlikely to be
+// used by something else does.
+const lockKey = 7261946382
+
+var (
+	ErrDirty            = errors.New("database is dirty, fix it by hand and run migrate force <version>")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrNothingToRevert  = errors.New("no migration has been applied")
+	errMalformedFileSet = errors.New("malformed migration file set")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version uint64
+	Name    string
+	Applied bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New reads the migrations from fsys. Every version needs an up file, down
+// files are optional. Any other .sql file is refused rather than skipped, a
+// migration with a mistyped name would otherwise never run.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s is not named <version>_<name>.up.sql or .down.sql", errMalformedFileSet, entry.Name())
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errMalformedFileSet, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %q and %q", errMalformedFileSet, version, m.Name, match[2])
+		}
+		script := &m.Up
+		if match[3] == "down" {
+			script = &m.Down
+		}
+		if *script != "" {
+			return nil, fmt.Errorf("%w: version %d has two %s files", errMalformedFileSet, version, match[3])
+		}
+		*script = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%w: version %d has no up file", errMalformedFileSet, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every migration newer than the current version and returns the
+// ones it applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(func(conn *sql.Conn) error {
+		current, dirty, err := version(conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := run(conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps migrations and returns the ones it reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(func(conn *sql.Conn) error {
+		current, dirty, err := version(conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		if current == 0 {
+			return ErrNothingToRevert
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := run(conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns the current version, whether it is dirty, and every known
+// migration with whether it has been applied.
+func (m *Migrator) Status() (uint64, bool, []Status, error) {
+	var current uint64
+	var dirty bool
+	var statuses []Status
+	err := m.locked(func(conn *sql.Conn) error {
+		var err error
+		current, dirty, err = version(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			statuses = append(statuses, Status{
+				Version: migration.Version,
+				Name:    migration.Name,
+				Applied: migration.Version <= current,
+			})
+		}
+		return nil
+	})
+	return current, dirty, statuses, err
+}
+
+// Force records version as applied and clean without running anything, to
+// recover from a migration that failed halfway. Version 0 means none applied.
+func (m *Migrator) Force(v uint64) error {
+	if v != 0 {
+		known := false
+		for _, migration := range m.migrations {
+			known = known || migration.Version == v
+		}
+		if !known {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, v)
+		}
+	}
+
+	return m.locked(func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := setVersion(tx, v); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// locked runs fn on a single connection holding the migration advisory lock.
+// The lock is session level, so it has to be taken and released on the very
+// connection the migrations run on.
+func (m *Migrator) locked(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func version(conn *sql.Conn) (uint64, bool, error) {
+	var v uint64
+	var dirty bool
+	err := conn.QueryRowContext(context.Background(), "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return v, dirty, err
+}
+
+// run executes a migration script and records the resulting version in the
+// same transaction, so a failing script leaves the database untouched.
+func run(conn *sql.Conn, script string, resulting uint64) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if err := setVersion(tx, resulting); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setVersion(tx *sql.Tx, v uint64) error {
+	if _, err := tx.Exec("DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if v == 0 {
+		return nil
+	}
+	_, err := tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", v)
+	return err
+}
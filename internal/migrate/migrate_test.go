@@ -0,0 +1,356 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeDB is a database/sql driver holding just schema_migrations. Scripts
+// are logged instead of run, a script containing FAIL fails. Changes to the
+// version only last when their transaction commits.
+type fakeDB struct {
+	mu      sync.Mutex
+	version uint64
+	dirty   bool
+	scripts []string
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{db: d}, nil }
+
+type fakeConn struct {
+	db *fakeDB
+	tx *fakeTx
+}
+
+type fakeTx struct {
+	conn    *fakeConn
+	version uint64
+	scripts []string
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("fake: prepare") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.tx = &fakeTx{conn: c, version: c.db.version}
+	return c.tx, nil
+}
+
+func (t *fakeTx) Commit() error {
+	db := t.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.version, db.dirty = t.version, false
+	db.scripts = append(db.scripts, t.scripts...)
+	t.conn.tx = nil
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.conn.tx = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.Contains(query, "pg_advisory"), strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		return driver.RowsAffected(0), nil
+	case c.tx == nil:
+		return nil, errors.New("fake: statement outside a transaction: " + query)
+	case query == "DELETE FROM schema_migrations":
+		c.tx.version = 0
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		c.tx.version = uint64(args[0].Value.(int64))
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("fake: script failed")
+	default:
+		c.tx.scripts = append(c.tx.scripts, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query != "SELECT version, dirty FROM schema_migrations LIMIT 1" {
+		return nil, errors.New("fake: unexpected query " + query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rows := &fakeRows{}
+	if c.db.version != 0 || c.db.dirty {
+		rows.values = [][]driver.Value{{int64(c.db.version), c.db.dirty}}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "dirty"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.db.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.db }
+
+// files is a migration set of versions 1, 2 and 10, listed out of order.
+var files = fstest.MapFS{
+	"10_add_index.up.sql":          {Data: []byte("CREATE INDEX")},
+	"10_add_index.down.sql":        {Data: []byte("DROP INDEX")},
+	"000002_add_column.up.sql":     {Data: []byte("ALTER TABLE ADD")},
+	"000002_add_column.down.sql":   {Data: []byte("ALTER TABLE DROP")},
+	"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE")},
+	"000001_create_table.down.sql": {Data: []byte("DROP TABLE")},
+	"migrations.go":                {Data: []byte("package migrations")},
+}
+
+func newMigrator(t *testing.T, fsys fstest.MapFS, db *fakeDB) *Migrator {
+	t.Helper()
+	sqlDB := sql.OpenDB(fakeConnector{db})
+	t.Cleanup(func() { sqlDB.Close() })
+	m, err := New(sqlDB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []uint64
+		err      error
+	}{
+		{"ordered by version, not by name", files, []uint64{1, 2, 10}, nil},
+		{"down file optional", fstest.MapFS{
+			"000001_a.up.sql": {Data: []byte("A")},
+		}, []uint64{1}, nil},
+		{"empty", fstest.MapFS{}, []uint64{}, nil},
+		{"other files ignored", fstest.MapFS{
+			"README.md":       {Data: []byte("docs")},
+			"000001_a.up.sql": {Data: []byte("A")},
+		}, []uint64{1}, nil},
+		{"non-numeric prefix", fstest.MapFS{
+			"v1_a.up.sql":     {Data: []byte("A")},
+			"000002_b.up.sql": {Data: []byte("B")},
+		}, nil, errMalformedFileSet},
+		{"neither up nor down", fstest.MapFS{
+			"000001_a.sql": {Data: []byte("A")},
+		}, nil, errMalformedFileSet},
+		{"same version padded differently", fstest.MapFS{
+			"000001_a.up.sql": {Data: []byte("A")},
+			"1_a.up.sql":      {Data: []byte("A again")},
+		}, nil, errMalformedFileSet},
+		{"missing up file", fstest.MapFS{
+			"000001_a.up.sql":   {Data: []byte("A")},
+			"000002_b.down.sql": {Data: []byte("B")},
+		}, nil, errMalformedFileSet},
+		{"duplicate version", fstest.MapFS{
+			"000001_a.up.sql": {Data: []byte("A")},
+			"000001_b.up.sql": {Data: []byte("B")},
+		}, nil, errMalformedFileSet},
+		{"version out of range", fstest.MapFS{
+			"99999999999999999999_a.up.sql": {Data: []byte("A")},
+		}, nil, errMalformedFileSet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(nil, tt.fsys)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			versions := []uint64{}
+			for _, migration := range m.migrations {
+				versions = append(versions, migration.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("versions %v, want %v", versions, tt.versions)
+			}
+		})
+	}
+}
+
+func TestNewPairsUpAndDown(t *testing.T) {
+	m, err := New(nil, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Migration{Version: 2, Name: "add_column", Up: "ALTER TABLE ADD", Down: "ALTER TABLE DROP"}
+	if m.migrations[1] != want {
+		t.Errorf("migration %+v, want %+v", m.migrations[1], want)
+	}
+}
+
+func TestUp(t *testing.T) {
+	db := &fakeDB{version: 1}
+	m := newMigrator(t, files, db)
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[0].Version != 2 || applied[1].Version != 10 {
+		t.Errorf("applied %+v, want 2 and 10", applied)
+	}
+	if db.version != 10 || !reflect.DeepEqual(db.scripts, []string{"ALTER TABLE ADD", "CREATE INDEX"}) {
+		t.Errorf("version %d scripts %q", db.version, db.scripts)
+	}
+
+	// Up to date
+	applied, err = m.Up()
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second Up applied %+v, err %v", applied, err)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_a.up.sql": {Data: []byte("A")},
+		"000002_b.up.sql": {Data: []byte("FAIL")},
+		"000003_c.up.sql": {Data: []byte("C")},
+	}
+	db := &fakeDB{}
+	m := newMigrator(t, fsys, db)
+
+	applied, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "migration 2_b") {
+		t.Fatalf("err = %v, want migration 2_b to fail", err)
+	}
+	if len(applied) != 1 || db.version != 1 || !reflect.DeepEqual(db.scripts, []string{"A"}) {
+		t.Errorf("applied %+v, version %d, scripts %q", applied, db.version, db.scripts)
+	}
+}
+
+func TestDirty(t *testing.T) {
+	m := newMigrator(t, files, &fakeDB{version: 2, dirty: true})
+	if _, err := m.Up(); !errors.Is(err, ErrDirty) {
+		t.Errorf("Up: err = %v, want ErrDirty", err)
+	}
+	if _, err := m.Down(1); !errors.Is(err, ErrDirty) {
+		t.Errorf("Down: err = %v, want ErrDirty", err)
+	}
+}
+
+func TestDown(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    int
+		version  uint64
+		reverted []uint64
+		scripts  []string
+	}{
+		{"one step", 1, 2, []uint64{10}, []string{"DROP INDEX"}},
+		{"two steps", 2, 1, []uint64{10, 2}, []string{"DROP INDEX", "ALTER TABLE DROP"}},
+		{"every step", 3, 0, []uint64{10, 2, 1}, []string{"DROP INDEX", "ALTER TABLE DROP", "DROP TABLE"}},
+		{"more steps than applied", 5, 0, []uint64{10, 2, 1}, []string{"DROP INDEX", "ALTER TABLE DROP", "DROP TABLE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{version: 10}
+			reverted, err := newMigrator(t, files, db).Down(tt.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			versions := []uint64{}
+			for _, migration := range reverted {
+				versions = append(versions, migration.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.reverted) {
+				t.Errorf("reverted %v, want %v", versions, tt.reverted)
+			}
+			if db.version != tt.version || !reflect.DeepEqual(db.scripts, tt.scripts) {
+				t.Errorf("version %d scripts %q, want version %d scripts %q", db.version, db.scripts, tt.version, tt.scripts)
+			}
+		})
+	}
+}
+
+func TestDownSkipsUnapplied(t *testing.T) {
+	db := &fakeDB{version: 2}
+	reverted, err := newMigrator(t, files, db).Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 || db.version != 1 {
+		t.Errorf("reverted %+v to version %d, want 2 reverted to 1", reverted, db.version)
+	}
+}
+
+func TestDownErrors(t *testing.T) {
+	if _, err := newMigrator(t, files, &fakeDB{}).Down(1); !errors.Is(err, ErrNothingToRevert) {
+		t.Errorf("nothing applied: err = %v, want ErrNothingToRevert", err)
+	}
+
+	fsys := fstest.MapFS{
+		"000001_a.up.sql":   {Data: []byte("A")},
+		"000001_a.down.sql": {Data: []byte("UNDO A")},
+		"000002_b.up.sql":   {Data: []byte("B")},
+	}
+	db := &fakeDB{version: 2}
+	if _, err := newMigrator(t, fsys, db).Down(1); !errors.Is(err, ErrNoDownMigration) {
+		t.Errorf("no down file: err = %v, want ErrNoDownMigration", err)
+	}
+	if db.version != 2 {
+		t.Errorf("version %d after a failed Down, want 2", db.version)
+	}
+}
+
+func TestForce(t *testing.T) {
+	db := &fakeDB{version: 10, dirty: true}
+	m := newMigrator(t, files, db)
+
+	if err := m.Force(7); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("err = %v, want ErrUnknownVersion", err)
+	}
+	if err := m.Force(2); err != nil {
+		t.Fatal(err)
+	}
+	if db.version != 2 || db.dirty || len(db.scripts) != 0 {
+		t.Errorf("version %d dirty %v scripts %q, want clean 2 with nothing run", db.version, db.dirty, db.scripts)
+	}
+	if err := m.Force(0); err != nil || db.version != 0 {
+		t.Errorf("Force(0): version %d, err %v", db.version, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	current, dirty, statuses, err := newMigrator(t, files, &fakeDB{version: 2}).Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Status{
+		{Version: 1, Name: "create_table", Applied: true},
+		{Version: 2, Name: "add_column", Applied: true},
+		{Version: 10, Name: "add_index", Applied: false},
+	}
+	if current != 2 || dirty || !reflect.DeepEqual(statuses, want) {
+		t.Errorf("current %d dirty %v statuses %+v", current, dirty, statuses)
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import "strings"
+
+// NormalizeBarcode checks an EAN-13 or UPC-A code and returns it in the
+// 13 digit form barcodes are stored in. Scanners often send a trailing
+// newline or tab, surrounding whitespace is ignored. UPC-A is EAN-13 with a
+// leading zero, so the check digit carries over unchanged.
+func NormalizeBarcode(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	if len(code) != 13 {
+		return "", false
+	}
+
+	sum := 0
+	for i := 0; i < 13; i++ {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return "", false
+		}
+		digit := int(c - '0')
+		if i == 12 {
+			if (10-sum%10)%10 != digit {
+				return "", false
+			}
+			break
+		}
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return code, true
+}
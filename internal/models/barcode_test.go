@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestNormalizeBarcode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+		ok   bool
+	}{
+		{"EAN-13", "4006381333931", "4006381333931", true},
+		{"EAN-13 Indonesian prefix", "8991002101630", "8991002101630", true},
+		{"EAN-13 wrong check digit", "4006381333932", "", false},
+		{"EAN-13 digits swapped", "4006383133931", "", false},
+		{"UPC-A", "036000291452", "0036000291452", true},
+		{"UPC-A as EAN-13", "0036000291452", "0036000291452", true},
+		{"UPC-A wrong check digit", "036000291453", "", false},
+		{"trailing newline", "4006381333931\n", "4006381333931", true},
+		{"surrounding whitespace", " \t036000291452\r\n", "0036000291452", true},
+		{"inner space", "400638 1333931", "", false},
+		{"leading zeros kept", "0000000000017", "0000000000017", true},
+		{"all zeros", "0000000000000", "0000000000000", true},
+		{"UPC-A with leading zeros", "012345678905", "0012345678905", true},
+		{"leading zero dropped", "36000291452", "", false},
+		{"too long", "04006381333931", "", false},
+		{"EAN-8", "96385074", "", false},
+		{"letters", "40063813339X1", "", false},
+		{"empty", "", "", false},
+		{"blank", "   ", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeBarcode(tt.code)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeBarcode(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
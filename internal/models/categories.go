@@ -1,7 +1,11 @@
 package models
 
+// Category optionally overrides the outlet tax rule for its products. A nil
+// TaxRate or TaxInclusive falls back to the outlet setting.
 type Category struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name" validate:"required,max=255"`
+	Description  string   `json:"description"`
+	TaxRate      *float64 `json:"tax_rate,omitempty" validate:"min=0,max=100"`
+	TaxInclusive *bool    `json:"tax_inclusive,omitempty"`
 }
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+type ClosingType string
+
+const (
+	ClosingX ClosingType = "x"
+	ClosingZ ClosingType = "z"
+)
+
+// DayClosing totals one business day. The X report is worked out from the
+// sales as they stand and can be printed as often as needed while the day is
+// open; the Z report is the closing itself, stored once when the day is
+// closed and unchanged from then on. TotalSales is what the day's sales came
+// to and TotalRevenue that less the refunds made on the day. TotalTax and
+// TotalServiceCharge are net of the refunds, as in the sales report.
+//
+// ExpectedCash is the opening float plus the cash taken, net of change, less
+// CashRefund, the refunds paid back from the drawer: a refund is paid back
+// the way its sale was paid, so it takes the share of the sale that was
+// tendered in cash out of the drawer. CountedCash and
+// CashDifference (counted - expected) are null on an X report unless the cash
+// was counted for it.
+type DayClosing struct {
+	ID                 int              `json:"id,omitempty"`
+	Type               ClosingType      `json:"type" enums:"x,z"`
+	BusinessDate       string           `json:"business_date" example:"2026-10-18"`
+	PeriodStart        time.Time        `json:"period_start"`
+	PeriodEnd          time.Time        `json:"period_end"`
+	Transactions       int              `json:"transactions"`
+	GrossSales         int              `json:"gross_sales"`
+	TotalDiscount      int              `json:"total_discount"`
+	TotalTax           int              `json:"total_tax"`
+	TotalServiceCharge int              `json:"total_service_charge"`
+	TotalSales         int              `json:"total_sales"`
+	Refunds            int              `json:"refunds"`
+	TotalRefund        int              `json:"total_refund"`
+	TotalRevenue       int              `json:"total_revenue"`
+	Payments           []PaymentSummary `json:"payments,omitempty"`
+	OpeningCash        int              `json:"opening_cash"`
+	CashSales          int              `json:"cash_sales"`
+	CashRefund         int              `json:"cash_refund"`
+	ExpectedCash       int              `json:"expected_cash"`
+	CountedCash        *int             `json:"counted_cash"`
+	CashDifference     *int             `json:"cash_difference"`
+	Note               string           `json:"note,omitempty"`
+	ClosedBy           string           `json:"closed_by,omitempty"`
+	ClosedAt           *time.Time       `json:"closed_at,omitempty"`
+}
+
+// SettleCash works out the cash the drawer should hold, and how far the count
+// is off once the cash has been counted.
+func (c *DayClosing) SettleCash() {
+	c.CashSales = 0
+	for _, p := range c.Payments {
+		if p.Method == PaymentMethodCash {
+			c.CashSales = p.Amount
+		}
+	}
+	c.ExpectedCash = c.OpeningCash + c.CashSales - c.CashRefund
+	c.CashDifference = nil
+	if c.CountedCash != nil {
+		difference := *c.CountedCash - c.ExpectedCash
+		c.CashDifference = &difference
+	}
+}
+
+// DayClosingRequest closes a business day: the current one, or the earlier
+// one BusinessDate (YYYY-MM-DD) names when a day was left open.
+type DayClosingRequest struct {
+	BusinessDate string `json:"business_date,omitempty" example:"2026-10-18"`
+	OpeningCash  int    `json:"opening_cash" validate:"min=0"`
+	CountedCash  *int   `json:"counted_cash" validate:"required,min=0"`
+	Note         string `json:"note,omitempty" validate:"max=255"`
+}
+
+type DayClosingFilter struct {
+	Page    int
+	PerPage int
+}
+
+type DayClosingList struct {
+	Data    []DayClosing `json:"data"`
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+}
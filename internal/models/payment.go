@@ -0,0 +1,26 @@
+package models
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash    PaymentMethod = "cash"
+	PaymentMethodQRIS    PaymentMethod = "qris"
+	PaymentMethodDebit   PaymentMethod = "debit"
+	PaymentMethodEWallet PaymentMethod = "ewallet"
+)
+
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodQRIS, PaymentMethodDebit, PaymentMethodEWallet:
+		return true
+	}
+	return false
+}
+
+type Payment struct {
+	ID            int           `json:"id"`
+	TransactionID int           `json:"transaction_id"`
+	Method        PaymentMethod `json:"method" enums:"cash,qris,debit,ewallet"`
+	Amount        int           `json:"amount"`
+	Reference     string        `json:"reference,omitempty"`
+}
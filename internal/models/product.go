@@ -1,10 +1,54 @@
 package models
 
+// Product barcodes are EAN-13 or UPC-A. On update a missing barcodes field
+// keeps the current ones and an empty list removes them.
+//
+// CostPrice is the average cost of a unit in stock, kept up to date by goods
+// receipts. Setting it overrides the average, on update a missing cost_price
+// keeps the current one.
+//
+// Options and Variants are replaced together, an update leaving both out keeps
+// the current ones. Variants sent with an id are updated, those without one are
+// added and the ones left out are removed. A product with variants is sold per
+// variant and its stock is the sum of theirs.
+//
+// Stock is the opening stock of a new product or variant. An update ignores
+// it, stock is changed through stock movements so a sale or receipt made in
+// the meantime is never overwritten.
 type Product struct {
-	ID         int       `json:"id"`
-	Name       string    `json:"name"`
-	Price      int       `json:"price"`
-	Stock      int       `json:"stock"`
-	CategoryID int       `json:"category_id"`
-	Category   *Category `json:"category,omitempty"`
+	ID         int              `json:"id"`
+	SKU        string           `json:"sku,omitempty" validate:"max=64"`
+	Barcodes   []string         `json:"barcodes"`
+	Name       string           `json:"name" validate:"required,max=255"`
+	Price      int              `json:"price" validate:"min=0"`
+	CostPrice  *int             `json:"cost_price" validate:"min=0"`
+	Stock      int              `json:"stock" validate:"min=0"`
+	CategoryID int              `json:"category_id" validate:"min=0"`
+	Category   *Category        `json:"category,omitempty"`
+	Options    []ProductOption  `json:"options,omitempty" validate:"dive"`
+	Variants   []ProductVariant `json:"variants,omitempty" validate:"dive"`
+}
+
+// ProductFilter narrows the product listing. Sort is a comma separated list
+// of id, name, price and stock, each optionally prefixed with "-" for
+// descending order. A nil InStock lists products regardless of stock.
+type ProductFilter struct {
+	Name       string
+	CategoryID int
+	MinPrice   *int
+	MaxPrice   *int
+	InStock    *bool
+	Sort       string
+	Page       int
+	PerPage    int
+}
+
+// ProductList is one page of products. Next links to the following page and
+// is empty on the last one.
+type ProductList struct {
+	Data    []Product `json:"data"`
+	Total   int       `json:"total"`
+	Page    int       `json:"page"`
+	PerPage int       `json:"per_page"`
+	Next    string    `json:"next,omitempty"`
 }
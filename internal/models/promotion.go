@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+type PromotionType string
+
+const (
+	// PromotionTypePercentage takes Percent off the matching lines
+	PromotionTypePercentage PromotionType = "percentage"
+	// PromotionTypeFixed takes Amount off every matching unit
+	PromotionTypeFixed PromotionType = "fixed"
+	// PromotionTypeBuyXGetY gives GetQty units free for every BuyQty units bought
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionTypeMinSpend takes Amount, or Percent, off the cart once it reaches MinSpend
+	PromotionTypeMinSpend PromotionType = "min_spend"
+)
+
+func (t PromotionType) Valid() bool {
+	switch t {
+	case PromotionTypePercentage, PromotionTypeFixed, PromotionTypeBuyXGetY, PromotionTypeMinSpend:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount rule evaluated at checkout. Line promotions are
+// limited to ProductID or CategoryID when set and apply to every product
+// otherwise. StartsAt/EndsAt bound the dates the promotion runs, while
+// StartTime/EndTime ("HH:MM") restrict it to a daily window such as a happy
+// hour; a window whose end is before its start runs past midnight. Active
+// switches the promotion off when false; it is enabled when left out.
+type Promotion struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name"`
+	Type       PromotionType `json:"type" enums:"percentage,fixed,buy_x_get_y,min_spend"`
+	Percent    int           `json:"percent,omitempty"`
+	Amount     int           `json:"amount,omitempty"`
+	BuyQty     int           `json:"buy_qty,omitempty"`
+	GetQty     int           `json:"get_qty,omitempty"`
+	MinSpend   int           `json:"min_spend,omitempty"`
+	ProductID  int           `json:"product_id,omitempty"`
+	CategoryID int           `json:"category_id,omitempty"`
+	StartsAt   *time.Time    `json:"starts_at,omitempty"`
+	EndsAt     *time.Time    `json:"ends_at,omitempty"`
+	StartTime  string        `json:"start_time,omitempty" example:"15:00"`
+	EndTime    string        `json:"end_time,omitempty" example:"17:00"`
+	Active     *bool         `json:"active"`
+}
+
+// ActiveAt reports whether the promotion runs at the given moment.
+func (p Promotion) ActiveAt(at time.Time) bool {
+	if p.Active != nil && !*p.Active {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && at.After(*p.EndsAt) {
+		return false
+	}
+	if p.StartTime == "" || p.EndTime == "" {
+		return true
+	}
+
+	clock := at.Format("15:04")
+	if p.StartTime <= p.EndTime {
+		return clock >= p.StartTime && clock < p.EndTime
+	}
+	return clock >= p.StartTime || clock < p.EndTime
+}
+
+// Matches reports whether a line promotion applies to the given product.
+func (p Promotion) Matches(productID, categoryID int) bool {
+	if p.ProductID != 0 && p.ProductID != productID {
+		return false
+	}
+	if p.CategoryID != 0 && p.CategoryID != categoryID {
+		return false
+	}
+	return true
+}
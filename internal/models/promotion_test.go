@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotionActiveAt(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", "2026-10-18 "+clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	off, on := false, true
+	starts, ends := at("00:00").AddDate(0, 0, -1), at("00:00").AddDate(0, 0, 1)
+
+	tests := []struct {
+		name  string
+		promo Promotion
+		clock string
+		want  bool
+	}{
+		{"enabled when active is left out", Promotion{}, "12:00", true},
+		{"enabled", Promotion{Active: &on}, "12:00", true},
+		{"switched off", Promotion{Active: &off}, "12:00", false},
+		{"within dates", Promotion{StartsAt: &starts, EndsAt: &ends}, "12:00", true},
+		{"before start date", Promotion{StartsAt: &ends}, "12:00", false},
+		{"after end date", Promotion{EndsAt: &starts}, "12:00", false},
+		{"window start included", Promotion{StartTime: "15:00", EndTime: "17:00"}, "15:00", true},
+		{"inside window", Promotion{StartTime: "15:00", EndTime: "17:00"}, "16:59", true},
+		{"window end excluded", Promotion{StartTime: "15:00", EndTime: "17:00"}, "17:00", false},
+		{"before window", Promotion{StartTime: "15:00", EndTime: "17:00"}, "14:59", false},
+		{"overnight window evening", Promotion{StartTime: "22:00", EndTime: "02:00"}, "23:30", true},
+		{"overnight window midnight", Promotion{StartTime: "22:00", EndTime: "02:00"}, "00:00", true},
+		{"overnight window early morning", Promotion{StartTime: "22:00", EndTime: "02:00"}, "01:59", true},
+		{"overnight window end excluded", Promotion{StartTime: "22:00", EndTime: "02:00"}, "02:00", false},
+		{"overnight window daytime", Promotion{StartTime: "22:00", EndTime: "02:00"}, "12:00", false},
+		{"switched off inside window", Promotion{Active: &off, StartTime: "15:00", EndTime: "17:00"}, "16:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.ActiveAt(at(tt.clock)); got != tt.want {
+				t.Errorf("ActiveAt(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromotionMatches(t *testing.T) {
+	tests := []struct {
+		name                  string
+		promo                 Promotion
+		productID, categoryID int
+		want                  bool
+	}{
+		{"every product", Promotion{}, 1, 2, true},
+		{"same product", Promotion{ProductID: 1}, 1, 2, true},
+		{"other product", Promotion{ProductID: 3}, 1, 2, false},
+		{"same category", Promotion{CategoryID: 2}, 1, 2, true},
+		{"other category", Promotion{CategoryID: 4}, 1, 2, false},
+		{"uncategorised product", Promotion{CategoryID: 4}, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.Matches(tt.productID, tt.categoryID); got != tt.want {
+				t.Errorf("Matches(%d, %d) = %v, want %v", tt.productID, tt.categoryID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderOpen              PurchaseOrderStatus = "open"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// PurchaseOrder lists what is ordered from a supplier. It can be edited until
+// goods are received against it, cancelling it drops whatever is still
+// outstanding. TotalAmount is what the order is worth at the ordered unit
+// costs. Lines and receipts are only filled in on the detail.
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id" validate:"gt=0"`
+	SupplierName string              `json:"supplier_name"`
+	Status       PurchaseOrderStatus `json:"status" enums:"open,partially_received,received,cancelled"`
+	Note         string              `json:"note,omitempty" validate:"max=255"`
+	TotalAmount  int                 `json:"total_amount"`
+	CreatedBy    string              `json:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Lines        []PurchaseOrderLine `json:"lines,omitempty" validate:"required,dive,unique=ProductID+VariantID"`
+	Receipts     []GoodsReceipt      `json:"receipts,omitempty"`
+}
+
+// PurchaseOrderLine orders one product, or one variant of a product with
+// variants. ProductID and VariantID are 0 once they have been deleted, the
+// names are kept as they were when ordered.
+type PurchaseOrderLine struct {
+	ID               int    `json:"id"`
+	ProductID        int    `json:"product_id" validate:"gt=0"`
+	ProductName      string `json:"product_name"`
+	VariantID        int    `json:"variant_id,omitempty" validate:"min=0"`
+	VariantName      string `json:"variant_name,omitempty"`
+	Quantity         int    `json:"quantity" validate:"gt=0"`
+	UnitCost         int    `json:"unit_cost" validate:"min=0"`
+	ReceivedQuantity int    `json:"received_quantity"`
+}
+
+// GoodsReceipt records goods delivered against a purchase order, possibly
+// only part of it.
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	PurchaseOrderID int                `json:"purchase_order_id"`
+	Note            string             `json:"note,omitempty"`
+	CreatedBy       string             `json:"created_by,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Items           []GoodsReceiptItem `json:"items"`
+}
+
+type GoodsReceiptItem struct {
+	ID                  int `json:"id"`
+	PurchaseOrderLineID int `json:"purchase_order_line_id"`
+	Quantity            int `json:"quantity"`
+	UnitCost            int `json:"unit_cost"`
+}
+
+// GoodsReceiptRequest receives quantities against purchase order lines. The
+// unit cost defaults to the ordered one, set it when the invoice differs.
+type GoodsReceiptRequest struct {
+	Note  string             `json:"note,omitempty" validate:"max=255"`
+	Items []GoodsReceiptLine `json:"items" validate:"required,dive,unique=PurchaseOrderLineID"`
+}
+
+type GoodsReceiptLine struct {
+	PurchaseOrderLineID int  `json:"purchase_order_line_id" validate:"gt=0"`
+	Quantity            int  `json:"quantity" validate:"gt=0"`
+	UnitCost            *int `json:"unit_cost,omitempty" validate:"min=0"`
+}
+
+type PurchaseOrderFilter struct {
+	SupplierID int
+	Status     PurchaseOrderStatus
+	Page       int
+	PerPage    int
+}
+
+type PurchaseOrderList struct {
+	Data    []PurchaseOrder `json:"data"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"per_page"`
+}
+
+// OutstandingSupplier is what a supplier still has to deliver on its open
+// and partially received orders, valued at the ordered unit costs.
+type OutstandingSupplier struct {
+	SupplierID          int                   `json:"supplier_id"`
+	SupplierName        string                `json:"supplier_name"`
+	OutstandingQuantity int                   `json:"outstanding_quantity"`
+	OutstandingValue    int                   `json:"outstanding_value"`
+	PurchaseOrders      []OutstandingPurchase `json:"purchase_orders"`
+}
+
+type OutstandingPurchase struct {
+	PurchaseOrderID     int                 `json:"purchase_order_id"`
+	Status              PurchaseOrderStatus `json:"status"`
+	CreatedAt           time.Time           `json:"created_at"`
+	OutstandingQuantity int                 `json:"outstanding_quantity"`
+	OutstandingValue    int                 `json:"outstanding_value"`
+}
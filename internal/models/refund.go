@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type RefundType string
+
+const (
+	RefundTypeVoid   RefundType = "void"
+	RefundTypeRefund RefundType = "refund"
+)
+
+type Refund struct {
+	ID            int          `json:"id"`
+	TransactionID int          `json:"transaction_id"`
+	Type          RefundType   `json:"type" enums:"void,refund"`
+	Reason        string       `json:"reason"`
+	Amount        int          `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Items         []RefundItem `json:"items"`
+}
+
+type RefundItem struct {
+	ID                  int `json:"id"`
+	RefundID            int `json:"refund_id"`
+	TransactionDetailID int `json:"transaction_detail_id"`
+	ProductID           int `json:"product_id"`
+	Quantity            int `json:"quantity"`
+	Amount              int `json:"amount"`
+	TaxBase             int `json:"tax_base"`
+	TaxAmount           int `json:"tax_amount"`
+	ServiceCharge       int `json:"service_charge"`
+	CostAmount          int `json:"cost_amount"`
+}
+
+type VoidRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RefundItemRequest struct {
+	TransactionDetailID int `json:"transaction_detail_id"`
+	Quantity            int `json:"quantity"`
+}
+
+type RefundRequest struct {
+	Reason string              `json:"reason"`
+	Items  []RefundItemRequest `json:"items"`
+}
@@ -1,12 +1,133 @@
 package models
 
+import "time"
+
 type ProductBestSeller struct {
 	Name       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
 
+// TaxSummary is the tax collected at one rate, net of refunds, as needed for
+// the PPN return: TaxBase is the DPP (dasar pengenaan pajak).
+type TaxSummary struct {
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+	TaxBase   int     `json:"tax_base"`
+	TaxAmount int     `json:"tax_amount"`
+}
+
+// CostingMethod decides what a sale costs: the average cost price of the
+// stock, or the cost of the oldest stock still on hand (FIFO).
+type CostingMethod string
+
+const (
+	CostingAverage CostingMethod = "average"
+	CostingFIFO    CostingMethod = "fifo"
+)
+
+// ProfitSummary is the gross profit on a product or a category. NetSales is
+// the sales before tax, after discounts, and GrossMargin is GrossProfit in
+// percent of it.
+type ProfitSummary struct {
+	ID              int     `json:"id,omitempty"`
+	Name            string  `json:"name"`
+	Quantity        int     `json:"quantity"`
+	NetSales        int     `json:"net_sales"`
+	CostOfGoodsSold int     `json:"cost_of_goods_sold"`
+	GrossProfit     int     `json:"gross_profit"`
+	GrossMargin     float64 `json:"gross_margin"`
+}
+
+// ReportSection is an optional part of the sales report, asked for with
+// include=.
+type ReportSection string
+
+const (
+	ReportTopProducts ReportSection = "products"
+	ReportCategories  ReportSection = "categories"
+	ReportHeatmap     ReportSection = "heatmap"
+	ReportPayments    ReportSection = "payments"
+	ReportBasket      ReportSection = "basket"
+)
+
+// ReportOptions picks the optional sections of a sales report. Top is how
+// many products the products section lists.
+type ReportOptions struct {
+	Include []ReportSection
+	Top     int
+}
+
+func (o ReportOptions) Has(section ReportSection) bool {
+	for _, s := range o.Include {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// SalesBreakdown is what a product or a category sold in a period. Revenue
+// is the money collected for it including tax and service charge, less
+// refunds. Uncategorised sales have no id and an empty name.
+type SalesBreakdown struct {
+	ID       int    `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Revenue  int    `json:"revenue"`
+}
+
+// HeatmapCell counts the sales made in one hour of one day of the week,
+// DayOfWeek 0 is Sunday. Refunds are left out, it shows when customers come.
+type HeatmapCell struct {
+	DayOfWeek    int `json:"day_of_week"`
+	Hour         int `json:"hour"`
+	Transactions int `json:"transactions"`
+	Revenue      int `json:"revenue"`
+}
+
+// PaymentSummary is the money taken with one payment method, cash net of the
+// change given. Refunds are not paid back per method and are left out.
+type PaymentSummary struct {
+	Method       PaymentMethod `json:"method"`
+	Transactions int           `json:"transactions"`
+	Amount       int           `json:"amount"`
+}
+
+// BasketSummary describes the average sale in a period, voided sales left
+// out.
+type BasketSummary struct {
+	AverageBasket       int     `json:"average_basket"`
+	ItemsPerTransaction float64 `json:"items_per_transaction"`
+}
+
+// SalesReport summarises a period. GrossSales and TotalDiscount cover the
+// sales made in the period; TotalRevenue is the money collected for them less
+// the refunds made in the period. TotalTax and TotalServiceCharge are net of
+// the tax and service charge those refunds gave back, and match the
+// TaxBreakdown. The profit figures net out refunds the same way. The sections after them are only
+// filled in when asked for. The period runs from PeriodStart up to, not
+// including, PeriodEnd: whole business days in the store's time zone.
 type SalesReport struct {
-	TotalRevenue   int               `json:"total_revenue"`
-	TotalTransaksi int               `json:"total_transaksi"`
-	ProdukTerlaris ProductBestSeller `json:"produk_terlaris"`
+	PeriodStart        time.Time         `json:"period_start"`
+	PeriodEnd          time.Time         `json:"period_end"`
+	GrossSales         int               `json:"gross_sales"`
+	TotalDiscount      int               `json:"total_discount"`
+	TotalTax           int               `json:"total_tax"`
+	TotalServiceCharge int               `json:"total_service_charge"`
+	TaxBreakdown       []TaxSummary      `json:"tax_breakdown"`
+	TotalRevenue       int               `json:"total_revenue"`
+	TotalRefund        int               `json:"total_refund"`
+	TotalTransaksi     int               `json:"total_transaksi"`
+	ProdukTerlaris     ProductBestSeller `json:"produk_terlaris"`
+	NetSales           int               `json:"net_sales"`
+	CostOfGoodsSold    int               `json:"cost_of_goods_sold"`
+	GrossProfit        int               `json:"gross_profit"`
+	GrossMargin        float64           `json:"gross_margin"`
+	ProfitByProduct    []ProfitSummary   `json:"profit_by_product"`
+	ProfitByCategory   []ProfitSummary   `json:"profit_by_category"`
+	TopProducts        []SalesBreakdown  `json:"top_products,omitempty"`
+	Categories         []SalesBreakdown  `json:"categories,omitempty"`
+	Heatmap            []HeatmapCell     `json:"heatmap,omitempty"`
+	Payments           []PaymentSummary  `json:"payments,omitempty"`
+	Basket             *BasketSummary    `json:"basket,omitempty"`
 }
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type StockMovementReason string
+
+const (
+	StockMovementSale       StockMovementReason = "sale"
+	StockMovementRefund     StockMovementReason = "refund"
+	StockMovementAdjustment StockMovementReason = "adjustment"
+	StockMovementPurchase   StockMovementReason = "purchase"
+	StockMovementTransfer   StockMovementReason = "transfer"
+)
+
+// StockMovement is one entry of the stock ledger. Quantity is signed, stock
+// going out is negative. Balance is the stock right after the movement, of the
+// variant when VariantID is set and of the product otherwise. ReferenceID
+// points at the transaction for a sale, the refund for a refund and the goods
+// receipt for a purchase. UnitCost is what a unit of stock coming in cost: the
+// received cost for a purchase, the cost of the sale for a refund and the cost
+// price for anything else. The product and variant names are kept as they
+// were, ProductID and VariantID are 0 once the product or variant is deleted.
+type StockMovement struct {
+	ID          int64               `json:"id"`
+	ProductID   int                 `json:"product_id"`
+	ProductName string              `json:"product_name"`
+	VariantID   int                 `json:"variant_id,omitempty"`
+	VariantName string              `json:"variant_name,omitempty"`
+	Quantity    int                 `json:"quantity"`
+	Balance     int                 `json:"balance"`
+	Reason      StockMovementReason `json:"reason" enums:"sale,refund,adjustment,purchase,transfer"`
+	ReferenceID int                 `json:"reference_id,omitempty"`
+	UnitCost    int                 `json:"unit_cost,omitempty"`
+	Note        string              `json:"note,omitempty"`
+	CreatedBy   string              `json:"created_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// StockMovementRequest records a movement by hand. Only adjustments and
+// transfers can be posted, sales, refunds and purchases are recorded by the
+// operations they come from.
+type StockMovementRequest struct {
+	ProductID   int                 `json:"product_id" validate:"gt=0"`
+	VariantID   int                 `json:"variant_id,omitempty" validate:"min=0"`
+	Quantity    int                 `json:"quantity"`
+	Reason      StockMovementReason `json:"reason" enums:"adjustment,transfer"`
+	ReferenceID int                 `json:"reference_id,omitempty" validate:"min=0"`
+	Note        string              `json:"note,omitempty" validate:"max=255"`
+}
+
+type StockMovementFilter struct {
+	ProductID int
+	VariantID int
+	Reason    StockMovementReason
+	Page      int
+	PerPage   int
+}
+
+type StockMovementList struct {
+	Data    []StockMovement `json:"data"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"per_page"`
+}
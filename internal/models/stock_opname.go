@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+type StockOpnameStatus string
+
+const (
+	StockOpnameOpen      StockOpnameStatus = "open"
+	StockOpnamePosted    StockOpnameStatus = "posted"
+	StockOpnameCancelled StockOpnameStatus = "cancelled"
+)
+
+// StockOpname is a physical stock count. Opening it freezes the expected
+// quantity of every product in scope, the whole catalogue or one category.
+// Posting adjusts each counted line by counted - expected through the stock
+// ledger; sales made while counting stay accounted for and nothing but stock
+// is touched. Lines left uncounted are not adjusted.
+type StockOpname struct {
+	ID         int                `json:"id"`
+	Status     StockOpnameStatus  `json:"status" enums:"open,posted,cancelled"`
+	CategoryID int                `json:"category_id,omitempty"`
+	Note       string             `json:"note,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ClosedBy   string             `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time         `json:"closed_at,omitempty"`
+	Summary    StockOpnameSummary `json:"summary"`
+	Items      []StockOpnameItem  `json:"items,omitempty"`
+}
+
+// StockOpnameItem is one product, or one variant, to count. UnitCost is the
+// cost price when the session was opened and values the variance. Counted,
+// Variance and VarianceValue are null until the line is counted.
+type StockOpnameItem struct {
+	ID            int        `json:"id"`
+	ProductID     int        `json:"product_id"`
+	ProductName   string     `json:"product_name"`
+	VariantID     int        `json:"variant_id,omitempty"`
+	VariantName   string     `json:"variant_name,omitempty"`
+	Expected      int        `json:"expected"`
+	Counted       *int       `json:"counted"`
+	Variance      *int       `json:"variance"`
+	UnitCost      int        `json:"unit_cost"`
+	VarianceValue *int       `json:"variance_value"`
+	CountedBy     string     `json:"counted_by,omitempty"`
+	CountedAt     *time.Time `json:"counted_at,omitempty"`
+}
+
+// StockOpnameSummary totals the variance of the counted lines. Shortage is
+// what is missing and surplus what was found on top, both as positive values.
+type StockOpnameSummary struct {
+	Items            int `json:"items"`
+	Counted          int `json:"counted"`
+	VarianceQuantity int `json:"variance_quantity"`
+	VarianceValue    int `json:"variance_value"`
+	ShortageValue    int `json:"shortage_value"`
+	SurplusValue     int `json:"surplus_value"`
+}
+
+type StockOpnameRequest struct {
+	CategoryID int    `json:"category_id,omitempty" validate:"min=0"`
+	Note       string `json:"note,omitempty" validate:"max=255"`
+}
+
+// StockCountRequest records counted quantities, a line counted again keeps
+// the latest count.
+type StockCountRequest struct {
+	Items []StockCount `json:"items" validate:"required,dive,unique=ProductID+VariantID"`
+}
+
+type StockCount struct {
+	ProductID int `json:"product_id" validate:"gt=0"`
+	VariantID int `json:"variant_id,omitempty" validate:"min=0"`
+	Counted   int `json:"counted" validate:"min=0"`
+}
+
+type StockOpnameFilter struct {
+	Status  StockOpnameStatus
+	Page    int
+	PerPage int
+}
+
+type StockOpnameList struct {
+	Data    []StockOpname `json:"data"`
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	PerPage int           `json:"per_page"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type Supplier struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name" validate:"required,max=255"`
+	ContactName string `json:"contact_name,omitempty" validate:"max=255"`
+	Phone       string `json:"phone,omitempty" validate:"max=32"`
+	Email       string `json:"email,omitempty" validate:"max=255"`
+	Address     string `json:"address,omitempty"`
+}
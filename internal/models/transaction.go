@@ -2,27 +2,110 @@ package models
 
 import "time"
 
+const (
+	TransactionStatusCompleted         = "completed"
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusRefunded          = "refunded"
+	TransactionStatusVoided            = "voided"
+)
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID              int                 `json:"id"`
+	Status          string              `json:"status" enums:"completed,partially_refunded,refunded,voided"`
+	GrossAmount     int                 `json:"gross_amount"`
+	DiscountAmount  int                 `json:"discount_amount"`
+	CartPromotionID int                 `json:"cart_promotion_id,omitempty"`
+	CartPromotion   string              `json:"cart_promotion,omitempty"`
+	Subtotal        int                 `json:"subtotal"`
+	TaxAmount       int                 `json:"tax_amount"`
+	ServiceCharge   int                 `json:"service_charge"`
+	TotalAmount     int                 `json:"total_amount"`
+	RefundedAmount  int                 `json:"refunded_amount"`
+	PaidAmount      int                 `json:"paid_amount"`
+	Change          int                 `json:"kembalian"`
+	IdempotencyKey  string              `json:"idempotency_key,omitempty"`
+	RequestHash     string              `json:"-"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Details         []TransactionDetail `json:"details"`
+	Payments        []Payment           `json:"payments"`
+	Refunds         []Refund            `json:"refunds"`
 }
 
+// TransactionDetail keeps the product name, price and category as they were
+// at sale time, so renaming or deleting a product does not rewrite history.
+// ProductID is 0 once the product has been deleted. DiscountAmount holds the
+// line promotion plus the line's share of the cart promotion, and Subtotal is
+// the line price after it. TaxBase (DPP) and TaxAmount split Subtotal for an
+// inclusive rule and add up to more than it for an exclusive one. CostAmount
+// is what the units sold cost by the costing method in use at sale time,
+// UnitCost is the same per unit.
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID             int     `json:"id"`
+	TransactionID  int     `json:"transaction_id"`
+	ProductID      int     `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	VariantID      int     `json:"variant_id,omitempty"`
+	VariantName    string  `json:"variant_name,omitempty"`
+	CategoryID     int     `json:"category_id,omitempty"`
+	CategoryName   string  `json:"category_name,omitempty"`
+	UnitPrice      int     `json:"unit_price"`
+	Quantity       int     `json:"quantity"`
+	DiscountAmount int     `json:"discount_amount"`
+	PromotionID    int     `json:"promotion_id,omitempty"`
+	PromotionName  string  `json:"promotion_name,omitempty"`
+	Subtotal       int     `json:"subtotal"`
+	TaxRate        float64 `json:"tax_rate"`
+	TaxInclusive   bool    `json:"tax_inclusive"`
+	TaxBase        int     `json:"tax_base"`
+	TaxAmount      int     `json:"tax_amount"`
+	ServiceCharge  int     `json:"service_charge"`
+	UnitCost       int     `json:"unit_cost"`
+	CostAmount     int     `json:"cost_amount"`
+}
+
+type StockShortage struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	VariantID   int    `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	Requested   int    `json:"requested"`
+	Available   int    `json:"available"`
 }
 
+// CheckoutItem names the product by product_id or by a scanned barcode.
+// Products with variants also need variant_id, unless the barcode is the
+// variant's own.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int    `json:"product_id,omitempty" validate:"gt=0"`
+	VariantID int    `json:"variant_id,omitempty" validate:"min=0"`
+	Barcode   string `json:"barcode,omitempty"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
 }
 
+// CheckoutRequest lists every product or variant once, a second line for the
+// same one is rejected rather than merged.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	IdempotencyKey string         `json:"idempotency_key,omitempty" validate:"max=255"`
+	Items          []CheckoutItem `json:"items" validate:"required,dive,unique=ProductID+VariantID"`
+	Payments       []Payment      `json:"payments"`
+}
+
+// TransactionFilter narrows a transaction listing. StartDate and EndDate come
+// in as dates and are turned into business days by the service, which hands
+// the repository the first moment of the range and the first one after it.
+type TransactionFilter struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+	MinAmount *int
+	MaxAmount *int
+	ProductID int
+	Page      int
+	PerPage   int
+}
+
+type TransactionList struct {
+	Data    []Transaction `json:"data"`
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	PerPage int           `json:"per_page"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import "strings"
+
+// ProductOption is an option type such as size or flavour with the values it
+// can take, in the order they are shown.
+type ProductOption struct {
+	Name   string   `json:"name" validate:"required,max=64"`
+	Values []string `json:"values" validate:"required"`
+}
+
+// ProductVariant is one combination of option values with its own price,
+// stock and barcodes. Options holds one value per option of the product, in
+// the same order. Barcodes and CostPrice follow the same rules as on Product.
+type ProductVariant struct {
+	ID        int      `json:"id"`
+	ProductID int      `json:"product_id"`
+	Name      string   `json:"name"`
+	Options   []string `json:"options" validate:"required"`
+	SKU       string   `json:"sku,omitempty" validate:"max=64"`
+	Barcodes  []string `json:"barcodes"`
+	Price     int      `json:"price" validate:"min=0"`
+	CostPrice *int     `json:"cost_price" validate:"min=0"`
+	Stock     int      `json:"stock" validate:"min=0"`
+}
+
+// VariantName is how a variant is labelled on receipts, e.g. "L / Less Sugar".
+func VariantName(options []string) string {
+	return strings.Join(options, " / ")
+}
+
+// Variant returns the variant with the given id, or nil when the product has
+// no such variant.
+func (p *Product) Variant(id int) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"kasir-api/internal/models"
@@ -8,14 +9,15 @@ import (
 
 var (
 	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryInUse    = errors.New("category still has products")
 )
 
 type CategoriesRepository interface {
-	GetAll() []models.Category
-	GetByID(id int) (*models.Category, error)
-	Create(c models.Category) models.Category
-	Update(id int, c models.Category) (*models.Category, error)
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]models.Category, error)
+	GetByID(ctx context.Context, id int) (*models.Category, error)
+	Create(ctx context.Context, c models.Category) (*models.Category, error)
+	Update(ctx context.Context, id int, c models.Category) (*models.Category, error)
+	Delete(ctx context.Context, id int) error
 }
 
 type postgresCategoryRepository struct {
@@ -26,51 +28,57 @@ func NewPostgresCategoryRepository(db *sql.DB) CategoriesRepository {
 	return &postgresCategoryRepository{db: db}
 }
 
-func (r *postgresCategoryRepository) GetAll() []models.Category {
-	rows, err := r.db.Query("SELECT id, name, description FROM categories")
+func (r *postgresCategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, description, tax_rate, tax_inclusive FROM categories")
 	if err != nil {
-		return []models.Category{}
+		return nil, err
 	}
 	defer rows.Close()
 
-	var categories []models.Category
+	categories := []models.Category{}
 	for rows.Next() {
 		var c models.Category
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description); err != nil {
-			continue
+		var taxRate sql.NullFloat64
+		var taxInclusive sql.NullBool
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &taxRate, &taxInclusive); err != nil {
+			return nil, err
 		}
+		setCategoryTax(&c, taxRate, taxInclusive)
 		categories = append(categories, c)
 	}
-	return categories
+	return categories, rows.Err()
 }
 
-func (r *postgresCategoryRepository) GetByID(id int) (*models.Category, error) {
+func (r *postgresCategoryRepository) GetByID(ctx context.Context, id int) (*models.Category, error) {
 	var c models.Category
-	err := r.db.QueryRow("SELECT id, name, description FROM categories WHERE id = $1", id).Scan(&c.ID, &c.Name, &c.Description)
+	var taxRate sql.NullFloat64
+	var taxInclusive sql.NullBool
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, description, tax_rate, tax_inclusive FROM categories WHERE id = $1", id).Scan(&c.ID, &c.Name, &c.Description, &taxRate, &taxInclusive)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrCategoryNotFound
 		}
 		return nil, err
 	}
+	setCategoryTax(&c, taxRate, taxInclusive)
 	return &c, nil
 }
 
-func (r *postgresCategoryRepository) Create(c models.Category) models.Category {
-	err := r.db.QueryRow(
-		"INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id",
-		c.Name, c.Description,
+func (r *postgresCategoryRepository) Create(ctx context.Context, c models.Category) (*models.Category, error) {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO categories (name, description, tax_rate, tax_inclusive) VALUES ($1, $2, $3, $4) RETURNING id",
+		c.Name, c.Description, c.TaxRate, c.TaxInclusive,
 	).Scan(&c.ID)
 	if err != nil {
-		return models.Category{}
+		return nil, err
 	}
-	return c
+	return &c, nil
 }
 
-func (r *postgresCategoryRepository) Update(id int, c models.Category) (*models.Category, error) {
-	result, err := r.db.Exec(
-		"UPDATE categories SET name = $1, description = $2 WHERE id = $3",
-		c.Name, c.Description, id,
+func (r *postgresCategoryRepository) Update(ctx context.Context, id int, c models.Category) (*models.Category, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE categories SET name = $1, description = $2, tax_rate = $3, tax_inclusive = $4 WHERE id = $5",
+		c.Name, c.Description, c.TaxRate, c.TaxInclusive, id,
 	)
 	if err != nil {
 		return nil, err
@@ -89,9 +97,12 @@ func (r *postgresCategoryRepository) Update(id int, c models.Category) (*models.
 	return &c, nil
 }
 
-func (r *postgresCategoryRepository) Delete(id int) error {
-	result, err := r.db.Exec("DELETE FROM categories WHERE id = $1", id)
+func (r *postgresCategoryRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
 	if err != nil {
+		if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "fk_products_categories" {
+			return ErrCategoryInUse
+		}
 		return err
 	}
 
@@ -107,6 +118,15 @@ func (r *postgresCategoryRepository) Delete(id int) error {
 	return nil
 }
 
+func setCategoryTax(c *models.Category, taxRate sql.NullFloat64, taxInclusive sql.NullBool) {
+	if taxRate.Valid {
+		c.TaxRate = &taxRate.Float64
+	}
+	if taxInclusive.Valid {
+		c.TaxInclusive = &taxInclusive.Bool
+	}
+}
+
 type MemoryCategoryRepository struct {
 	categories []models.Category
 }
@@ -128,11 +148,11 @@ func NewMemoryCategoryRepository() CategoriesRepository {
 	}
 }
 
-func (r *MemoryCategoryRepository) GetAll() []models.Category {
-	return r.categories
+func (r *MemoryCategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	return r.categories, nil
 }
 
-func (r *MemoryCategoryRepository) GetByID(id int) (*models.Category, error) {
+func (r *MemoryCategoryRepository) GetByID(ctx context.Context, id int) (*models.Category, error) {
 	for i := range r.categories {
 		if r.categories[i].ID == id {
 			return &r.categories[i], nil
@@ -141,24 +161,26 @@ func (r *MemoryCategoryRepository) GetByID(id int) (*models.Category, error) {
 	return nil, ErrCategoryNotFound
 }
 
-func (r *MemoryCategoryRepository) Create(c models.Category) models.Category {
+func (r *MemoryCategoryRepository) Create(ctx context.Context, c models.Category) (*models.Category, error) {
 	c.ID = len(r.categories) + 1
 	r.categories = append(r.categories, c)
-	return c
+	return &c, nil
 }
 
-func (r *MemoryCategoryRepository) Update(id int, updateData models.Category) (*models.Category, error) {
+func (r *MemoryCategoryRepository) Update(ctx context.Context, id int, updateData models.Category) (*models.Category, error) {
 	for i := range r.categories {
 		if r.categories[i].ID == id {
 			r.categories[i].Name = updateData.Name
 			r.categories[i].Description = updateData.Description
+			r.categories[i].TaxRate = updateData.TaxRate
+			r.categories[i].TaxInclusive = updateData.TaxInclusive
 			return &r.categories[i], nil
 		}
 	}
 	return nil, ErrCategoryNotFound
 }
 
-func (r *MemoryCategoryRepository) Delete(id int) error {
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, id int) error {
 	for i := range r.categories {
 		if r.categories[i].ID == id {
 			r.categories = append(r.categories[:i], r.categories[i+1:]...)
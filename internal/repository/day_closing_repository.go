@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"kasir-api/internal/audit"
+	"kasir-api/internal/models"
+	"time"
+)
+
+var (
+	ErrDayClosingNotFound = errors.New("business day has not been closed")
+	ErrDayAlreadyClosed   = errors.New("business day has already been closed")
+	ErrBusinessDayClosed  = errors.New("business day has been closed, its sales and refunds can no longer be changed")
+)
+
+type DayClosingRepository interface {
+	GetAll(ctx context.Context, filter models.DayClosingFilter) ([]models.DayClosing, int, error)
+	GetByDate(ctx context.Context, date string) (*models.DayClosing, error)
+	Totals(ctx context.Context, c *models.DayClosing) error
+	Close(ctx context.Context, c *models.DayClosing) error
+}
+
+type postgresDayClosingRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDayClosingRepository(db *sql.DB) DayClosingRepository {
+	return &postgresDayClosingRepository{db: db}
+}
+
+// queryer runs the queries of a report on the database or within a
+// transaction alike.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// businessDayClosed reports whether err is the database refusing a sale,
+// payment or refund within a closed business day.
+func businessDayClosed(err error) bool {
+	constraint, ok := constraintViolation(err, pqCheckViolation)
+	return ok && constraint == "chk_business_day_open"
+}
+
+const dayClosingColumns = `id, to_char(business_date, 'YYYY-MM-DD'), period_start, period_end, transactions, gross_sales,
+	total_discount, total_tax, total_service_charge, total_sales, refunds, total_refund, total_revenue, opening_cash,
+	cash_sales, cash_refund, expected_cash, counted_cash, cash_difference, COALESCE(note, ''), COALESCE(closed_by, ''), closed_at`
+
+func scanDayClosing(row interface{ Scan(...interface{}) error }) (models.DayClosing, error) {
+	c := models.DayClosing{Type: models.ClosingZ}
+	var counted, difference int
+	var closedAt time.Time
+	err := row.Scan(&c.ID, &c.BusinessDate, &c.PeriodStart, &c.PeriodEnd, &c.Transactions, &c.GrossSales,
+		&c.TotalDiscount, &c.TotalTax, &c.TotalServiceCharge, &c.TotalSales, &c.Refunds, &c.TotalRefund, &c.TotalRevenue,
+		&c.OpeningCash, &c.CashSales, &c.CashRefund, &c.ExpectedCash, &counted, &difference, &c.Note, &c.ClosedBy, &closedAt)
+	c.CountedCash, c.CashDifference, c.ClosedAt = &counted, &difference, &closedAt
+	return c, err
+}
+
+func (r *postgresDayClosingRepository) GetAll(ctx context.Context, filter models.DayClosingFilter) ([]models.DayClosing, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM day_closings").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+dayClosingColumns+" FROM day_closings ORDER BY business_date DESC LIMIT $1 OFFSET $2",
+		filter.PerPage, (filter.Page-1)*filter.PerPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	closings := []models.DayClosing{}
+	for rows.Next() {
+		c, err := scanDayClosing(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		closings = append(closings, c)
+	}
+	return closings, total, rows.Err()
+}
+
+// GetByDate returns the Z report of the business day, date as YYYY-MM-DD.
+func (r *postgresDayClosingRepository) GetByDate(ctx context.Context, date string) (*models.DayClosing, error) {
+	c, err := scanDayClosing(r.db.QueryRowContext(ctx, "SELECT "+dayClosingColumns+" FROM day_closings WHERE business_date = $1", date))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDayClosingNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT method, transactions, amount
+		FROM day_closing_payments
+		WHERE day_closing_id = $1
+		ORDER BY amount DESC, method
+	`, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c.Payments = []models.PaymentSummary{}
+	for rows.Next() {
+		var p models.PaymentSummary
+		if err := rows.Scan(&p.Method, &p.Transactions, &p.Amount); err != nil {
+			return nil, err
+		}
+		c.Payments = append(c.Payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Totals fills in the totals of the period of c as the sales stand now, for
+// the X report.
+func (r *postgresDayClosingRepository) Totals(ctx context.Context, c *models.DayClosing) error {
+	if err := dayTotals(ctx, r.db, c); err != nil {
+		return err
+	}
+	c.SettleCash()
+	return nil
+}
+
+// Close stores the Z report of the business day of c with its totals worked
+// out within the same transaction. From then on the database refuses sales,
+// payments and refunds within the period.
+func (r *postgresDayClosingRepository) Close(ctx context.Context, c *models.DayClosing) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Wait for the sales and refunds being recorded and hold off new ones
+	// until the closing is in, so the totals cover every row of the day
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE transactions, payments, refunds IN SHARE MODE"); err != nil {
+		return err
+	}
+	if err := dayTotals(ctx, tx, c); err != nil {
+		return err
+	}
+	c.SettleCash()
+
+	c.Type = models.ClosingZ
+	c.ClosedBy = audit.User(ctx)
+	var closedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO day_closings (business_date, period_start, period_end, transactions, gross_sales, total_discount,
+			total_tax, total_service_charge, total_sales, refunds, total_refund, total_revenue, opening_cash, cash_sales,
+			cash_refund, expected_cash, counted_cash, cash_difference, note, closed_by, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW())
+		RETURNING id, closed_at`,
+		c.BusinessDate, c.PeriodStart, c.PeriodEnd, c.Transactions, c.GrossSales, c.TotalDiscount,
+		c.TotalTax, c.TotalServiceCharge, c.TotalSales, c.Refunds, c.TotalRefund, c.TotalRevenue, c.OpeningCash, c.CashSales,
+		c.CashRefund, c.ExpectedCash, *c.CountedCash, *c.CashDifference, nullString(c.Note), nullString(c.ClosedBy),
+	).Scan(&c.ID, &closedAt)
+	if err != nil {
+		if constraint, ok := constraintViolation(err, pqUniqueViolation); ok && constraint == "uq_day_closings_business_date" {
+			return ErrDayAlreadyClosed
+		}
+		return err
+	}
+	c.ClosedAt = &closedAt
+
+	for _, p := range c.Payments {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO day_closing_payments (day_closing_id, method, transactions, amount) VALUES ($1, $2, $3, $4)",
+			c.ID, p.Method, p.Transactions, p.Amount,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// dayTotals totals the sales made in the period of c, the refunds made in it
+// and what was paid with each method. Tax and service charge are netted for
+// the refunds like in the sales report.
+func dayTotals(ctx context.Context, q queryer, c *models.DayClosing) error {
+	err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(gross_amount), 0), COALESCE(SUM(discount_amount), 0), COALESCE(SUM(tax_amount), 0),
+		       COALESCE(SUM(service_charge), 0), COALESCE(SUM(total_amount), 0)
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2
+	`, c.PeriodStart, c.PeriodEnd).Scan(&c.Transactions, &c.GrossSales, &c.TotalDiscount, &c.TotalTax,
+		&c.TotalServiceCharge, &c.TotalSales)
+	if err != nil {
+		return err
+	}
+
+	var refundedTax, refundedCharge int
+	err = refundTotals(ctx, q, c.PeriodStart, c.PeriodEnd).Scan(&c.Refunds, &c.TotalRefund, &refundedTax, &refundedCharge)
+	if err != nil {
+		return err
+	}
+	c.TotalRevenue = c.TotalSales - c.TotalRefund
+	c.TotalTax -= refundedTax
+	c.TotalServiceCharge -= refundedCharge
+
+	// A refund gives back the cash share of its sale, cash net of change
+	err = q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(ROUND(rf.amount::numeric * LEAST(cash.amount - t.change_amount, t.total_amount) / t.total_amount)), 0)::int
+		FROM refunds rf
+		JOIN transactions t ON t.id = rf.transaction_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(p.amount), 0) AS amount
+			FROM payments p
+			WHERE p.transaction_id = rf.transaction_id AND p.method = $3
+		) cash
+		WHERE rf.created_at >= $1 AND rf.created_at < $2 AND t.total_amount > 0 AND cash.amount > 0
+	`, c.PeriodStart, c.PeriodEnd, models.PaymentMethodCash).Scan(&c.CashRefund)
+	if err != nil {
+		return err
+	}
+
+	c.Payments, err = paymentSummary(ctx, q, c.PeriodStart, c.PeriodEnd)
+	return err
+}
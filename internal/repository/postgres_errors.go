@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the repositories translate into domain errors.
+const (
+	pqForeignKeyViolation = "23503"
+	pqCheckViolation      = "23514"
+	pqUniqueViolation     = "23505"
+)
+
+// constraintViolation reports whether err is a Postgres error with the given
+// code, and which constraint raised it.
+func constraintViolation(err error, code string) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && string(pqErr.Code) == code {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
@@ -1,21 +1,34 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"kasir-api/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 var (
-	ErrProductNotFound = errors.New("product not found")
+	ErrProductNotFound    = errors.New("product not found")
+	ErrUnknownCategory    = errors.New("category_id does not refer to an existing category")
+	ErrNegativeStock      = errors.New("stock must not be negative")
+	ErrDuplicateSKU       = errors.New("sku is already used by another product")
+	ErrDuplicateBarcode   = errors.New("barcode is already used by another product")
+	ErrDuplicateVariant   = errors.New("two variants have the same option values")
+	ErrUnknownVariant     = errors.New("variant does not belong to the product")
+	ErrInvalidProductSort = errors.New("unknown sort key, use id, name, price or stock with an optional leading -")
 )
 
 type ProductRepository interface {
-	GetAll(nameFilter string) []models.Product
-	GetByID(id int) (*models.Product, error)
-	Create(p models.Product) models.Product
-	Update(id int, p models.Product) (*models.Product, error)
-	Delete(id int) error
+	GetAll(ctx context.Context, filter models.ProductFilter) ([]models.Product, int, error)
+	GetByID(ctx context.Context, id int) (*models.Product, error)
+	GetByBarcode(ctx context.Context, code string) (*models.Product, int, error)
+	Create(ctx context.Context, p models.Product) (*models.Product, error)
+	Update(ctx context.Context, id int, p models.Product) (*models.Product, error)
+	Delete(ctx context.Context, id int) error
 }
 
 type postgresProductRepository struct {
@@ -26,71 +39,181 @@ func NewPostgresProductRepository(db *sql.DB) ProductRepository {
 	return &postgresProductRepository{db: db}
 }
 
-func (r *postgresProductRepository) GetAll(nameFilter string) []models.Product {
-	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, 
-		       c.id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-	`
+const productColumns = `p.id, p.sku, p.name, p.price, p.cost_price, p.stock, p.category_id,
+	ARRAY(SELECT b.code FROM product_barcodes b WHERE b.product_id = p.id AND b.variant_id IS NULL ORDER BY b.code),
+	c.id, c.name, c.description, c.tax_rate, c.tax_inclusive`
+
+const openingStockNote = "opening stock"
+
+// productSortColumns whitelists the sort keys, a leading "-" sorts descending.
+var productSortColumns = map[string]string{
+	"id":    "p.id",
+	"name":  "p.name",
+	"price": "p.price",
+	"stock": "p.stock",
+}
+
+func scanProduct(row interface{ Scan(...interface{}) error }) (models.Product, error) {
+	var p models.Product
+	var sku sql.NullString
+	var costPrice int
+	var categoryID sql.NullInt64
+	var cID sql.NullInt64
+	var cName sql.NullString
+	var cDesc sql.NullString
+	var cTaxRate sql.NullFloat64
+	var cTaxInclusive sql.NullBool
+
+	err := row.Scan(&p.ID, &sku, &p.Name, &p.Price, &costPrice, &p.Stock, &categoryID, pq.Array(&p.Barcodes),
+		&cID, &cName, &cDesc, &cTaxRate, &cTaxInclusive)
+	if err != nil {
+		return p, err
+	}
+
+	p.SKU = sku.String
+	p.CostPrice = &costPrice
+	if p.Barcodes == nil {
+		p.Barcodes = []string{}
+	}
+
+	if categoryID.Valid {
+		p.CategoryID = int(categoryID.Int64)
+		if cID.Valid {
+			p.Category = &models.Category{
+				ID:          int(cID.Int64),
+				Name:        cName.String,
+				Description: cDesc.String,
+			}
+			setCategoryTax(p.Category, cTaxRate, cTaxInclusive)
+		}
+	}
+	return p, nil
+}
+
+func (r *postgresProductRepository) GetAll(ctx context.Context, filter models.ProductFilter) ([]models.Product, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Name != "" {
+		addCondition("p.name ILIKE $%d", "%"+filter.Name+"%")
+	}
+	if filter.CategoryID != 0 {
+		addCondition("p.category_id = $%d", filter.CategoryID)
+	}
+	if filter.MinPrice != nil {
+		addCondition("p.price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		addCondition("p.price <= $%d", *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			conditions = append(conditions, "p.stock > 0")
+		} else {
+			conditions = append(conditions, "p.stock = 0")
+		}
+	}
 
-	args := []interface{}{}
-	if nameFilter != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	order, err := productOrder(filter.Sort)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	rows, err := r.db.Query(query, args...)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products p"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf(
+		"SELECT %s FROM products p LEFT JOIN categories c ON p.category_id = c.id%s ORDER BY %s LIMIT $%d OFFSET $%d",
+		productColumns, where, order, len(args)-1, len(args),
+	)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return []models.Product{}
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var products []models.Product
+	products := []models.Product{}
 	for rows.Next() {
-		var p models.Product
-		var categoryID sql.NullInt64
-		var cID sql.NullInt64
-		var cName sql.NullString
-		var cDesc sql.NullString
-
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID,
-			&cID, &cName, &cDesc); err != nil {
-			continue
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
 		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-		if categoryID.Valid {
-			p.CategoryID = int(categoryID.Int64)
-			if cID.Valid {
-				p.Category = &models.Category{
-					ID:          int(cID.Int64),
-					Name:        cName.String,
-					Description: cDesc.String,
-				}
-			}
+	if err := loadVariants(ctx, r.db, products); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// productOrder turns a comma separated list of sort keys into an ORDER BY
+// clause. The id always comes last so pages do not overlap on ties.
+func productOrder(sort string) (string, error) {
+	var terms []string
+	seenID := false
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
 		}
-		products = append(products, p)
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+			key = key[1:]
+		}
+		column, ok := productSortColumns[key]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", ErrInvalidProductSort, key)
+		}
+		seenID = seenID || key == "id"
+		terms = append(terms, column+" "+direction)
+	}
+	if !seenID {
+		terms = append(terms, "p.id ASC")
 	}
-	return products
+	return strings.Join(terms, ", "), nil
 }
 
-func (r *postgresProductRepository) GetByID(id int) (*models.Product, error) {
-	var p models.Product
-	var categoryID sql.NullInt64
-	var cID sql.NullInt64
-	var cName sql.NullString
-	var cDesc sql.NullString
+// GetByBarcode finds the product a scanned code belongs to, and the variant
+// when it is a variant's barcode (0 otherwise). code must already be
+// normalized to 13 digits.
+func (r *postgresProductRepository) GetByBarcode(ctx context.Context, code string) (*models.Product, int, error) {
+	var productID int
+	var variantID sql.NullInt64
+	err := r.db.QueryRowContext(ctx, "SELECT product_id, variant_id FROM product_barcodes WHERE code = $1", code).Scan(&productID, &variantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, ErrProductNotFound
+		}
+		return nil, 0, err
+	}
 
-	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, 
-		       c.id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
-	`
-	err := r.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID,
-		&cID, &cName, &cDesc)
+	p, err := r.GetByID(ctx, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p, int(variantID.Int64), nil
+}
 
+func (r *postgresProductRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	query := "SELECT " + productColumns + " FROM products p LEFT JOIN categories c ON p.category_id = c.id WHERE p.id = $1"
+	p, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrProductNotFound
@@ -98,38 +221,63 @@ func (r *postgresProductRepository) GetByID(id int) (*models.Product, error) {
 		return nil, err
 	}
 
-	if categoryID.Valid {
-		p.CategoryID = int(categoryID.Int64)
-		if cID.Valid {
-			p.Category = &models.Category{
-				ID:          int(cID.Int64),
-				Name:        cName.String,
-				Description: cDesc.String,
-			}
-		}
+	products := []models.Product{p}
+	if err := loadVariants(ctx, r.db, products); err != nil {
+		return nil, err
 	}
-	return &p, nil
+	return &products[0], nil
 }
 
-func (r *postgresProductRepository) Create(p models.Product) models.Product {
-	err := r.db.QueryRow(
-		"INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id",
-		p.Name, p.Price, p.Stock, p.CategoryID,
+func (r *postgresProductRepository) Create(ctx context.Context, p models.Product) (*models.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Stock starts at zero, the opening stock is the first ledger entry
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO products (sku, name, price, cost_price, stock, category_id) VALUES ($1, $2, $3, COALESCE($4, 0), 0, $5) RETURNING id",
+		nullString(p.SKU), p.Name, p.Price, p.CostPrice, nullInt(p.CategoryID),
 	).Scan(&p.ID)
 	if err != nil {
-		return models.Product{}
+		return nil, productWriteError(err)
+	}
+
+	if err := setBarcodes(ctx, tx, p.ID, 0, p.Barcodes); err != nil {
+		return nil, err
 	}
-	return p
+	if err := saveVariants(ctx, tx, p.ID, p.Options, p.Variants); err != nil {
+		return nil, err
+	}
+	if err := setStock(ctx, tx, p.ID, &p.Stock, openingStockNote); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, productWriteError(err)
+	}
+	return r.GetByID(ctx, p.ID)
 }
 
-func (r *postgresProductRepository) Update(id int, p models.Product) (*models.Product, error) {
-	result, err := r.db.Exec(
-		"UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5",
-		p.Name, p.Price, p.Stock, p.CategoryID, id,
-	)
+// Update overwrites the product. Its barcodes are only replaced when
+// p.Barcodes is not nil, its options and variants when either of them is not
+// nil. p.Stock is left alone, and so is the stock of the variants it keeps:
+// stock only changes through the ledger.
+func (r *postgresProductRepository) Update(ctx context.Context, id int, p models.Product) (*models.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE products SET sku = $1, name = $2, price = $3, cost_price = COALESCE($4, cost_price), category_id = $5 WHERE id = $6",
+		nullString(p.SKU), p.Name, p.Price, p.CostPrice, nullInt(p.CategoryID), id,
+	)
+	if err != nil {
+		return nil, productWriteError(err)
+	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -140,12 +288,44 @@ func (r *postgresProductRepository) Update(id int, p models.Product) (*models.Pr
 		return nil, ErrProductNotFound
 	}
 
-	p.ID = id
-	return &p, nil
+	if p.Barcodes != nil {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM product_barcodes WHERE product_id = $1 AND variant_id IS NULL", id); err != nil {
+			return nil, err
+		}
+		if err := setBarcodes(ctx, tx, id, 0, p.Barcodes); err != nil {
+			return nil, err
+		}
+	}
+	if p.Options != nil || p.Variants != nil {
+		if err := saveVariants(ctx, tx, id, p.Options, p.Variants); err != nil {
+			return nil, err
+		}
+	}
+	if err := setStock(ctx, tx, id, nil, "product edited"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, productWriteError(err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// setBarcodes adds barcodes to a product, or to one of its variants when
+// variantID is not 0.
+func setBarcodes(ctx context.Context, tx *sql.Tx, productID, variantID int, codes []string) error {
+	for _, code := range codes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO product_barcodes (code, product_id, variant_id) VALUES ($1, $2, $3)",
+			code, productID, nullInt(variantID))
+		if err != nil {
+			return productWriteError(err)
+		}
+	}
+	return nil
 }
 
-func (r *postgresProductRepository) Delete(id int) error {
-	result, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
+func (r *postgresProductRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM products WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
@@ -160,3 +340,26 @@ func (r *postgresProductRepository) Delete(id int) error {
 	}
 	return nil
 }
+
+// productWriteError turns the constraint violations an insert or update can
+// hit into errors the caller can act on.
+func productWriteError(err error) error {
+	if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "fk_products_categories" {
+		return ErrUnknownCategory
+	}
+	if constraint, ok := constraintViolation(err, pqCheckViolation); ok &&
+		(constraint == "chk_products_stock_non_negative" || constraint == "chk_product_variants_stock_non_negative") {
+		return ErrNegativeStock
+	}
+	if constraint, ok := constraintViolation(err, pqUniqueViolation); ok {
+		switch constraint {
+		case "idx_products_sku", "uq_product_variants_sku":
+			return ErrDuplicateSKU
+		case "uq_product_variants_options":
+			return ErrDuplicateVariant
+		case "product_barcodes_pkey":
+			return ErrDuplicateBarcode
+		}
+	}
+	return err
+}
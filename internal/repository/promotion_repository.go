@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"kasir-api/internal/models"
+	"time"
+)
+
+var (
+	ErrPromotionNotFound      = errors.New("promotion not found")
+	ErrUnknownPromotionTarget = errors.New("product_id or category_id does not exist")
+)
+
+type PromotionRepository interface {
+	GetAll(ctx context.Context) ([]models.Promotion, error)
+	GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error)
+	GetByID(ctx context.Context, id int) (*models.Promotion, error)
+	Create(ctx context.Context, p models.Promotion) (*models.Promotion, error)
+	Update(ctx context.Context, id int, p models.Promotion) (*models.Promotion, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type postgresPromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPromotionRepository(db *sql.DB) PromotionRepository {
+	return &postgresPromotionRepository{db: db}
+}
+
+const promotionColumns = `id, name, type, percent, amount, buy_qty, get_qty, min_spend,
+	product_id, category_id, starts_at, ends_at, start_time, end_time, active`
+
+func scanPromotion(row interface{ Scan(...interface{}) error }) (models.Promotion, error) {
+	var p models.Promotion
+	var productID, categoryID sql.NullInt64
+	var startsAt, endsAt sql.NullTime
+	var startTime, endTime sql.NullString
+	var active bool
+
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Percent, &p.Amount, &p.BuyQty, &p.GetQty, &p.MinSpend,
+		&productID, &categoryID, &startsAt, &endsAt, &startTime, &endTime, &active)
+	if err != nil {
+		return p, err
+	}
+	p.Active = &active
+
+	p.ProductID = int(productID.Int64)
+	p.CategoryID = int(categoryID.Int64)
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+	// TIME comes back as HH:MM:SS
+	if startTime.Valid && len(startTime.String) >= 5 {
+		p.StartTime = startTime.String[:5]
+	}
+	if endTime.Valid && len(endTime.String) >= 5 {
+		p.EndTime = endTime.String[:5]
+	}
+	return p, nil
+}
+
+func (r *postgresPromotionRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+func (r *postgresPromotionRepository) GetAll(ctx context.Context) ([]models.Promotion, error) {
+	return r.query(ctx, "SELECT "+promotionColumns+" FROM promotions ORDER BY id")
+}
+
+// GetActive returns the enabled promotions whose date range covers at. The
+// daily time window is left to models.Promotion.ActiveAt.
+func (r *postgresPromotionRepository) GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	return r.query(ctx, `
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE active
+		  AND (starts_at IS NULL OR starts_at <= $1)
+		  AND (ends_at IS NULL OR ends_at >= $1)
+		ORDER BY id
+	`, at)
+}
+
+func (r *postgresPromotionRepository) GetByID(ctx context.Context, id int) (*models.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRowContext(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *postgresPromotionRepository) Create(ctx context.Context, p models.Promotion) (*models.Promotion, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO promotions (name, type, percent, amount, buy_qty, get_qty, min_spend,
+			product_id, category_id, starts_at, ends_at, start_time, end_time, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`, promotionArgs(p)...).Scan(&p.ID)
+	if err != nil {
+		return nil, promotionWriteError(err)
+	}
+	return &p, nil
+}
+
+func (r *postgresPromotionRepository) Update(ctx context.Context, id int, p models.Promotion) (*models.Promotion, error) {
+	args := append(promotionArgs(p), id)
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE promotions SET name = $1, type = $2, percent = $3, amount = $4, buy_qty = $5, get_qty = $6,
+			min_spend = $7, product_id = $8, category_id = $9, starts_at = $10, ends_at = $11,
+			start_time = $12, end_time = $13, active = $14
+		WHERE id = $15
+	`, args...)
+	if err != nil {
+		return nil, promotionWriteError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrPromotionNotFound
+	}
+
+	p.ID = id
+	return &p, nil
+}
+
+func (r *postgresPromotionRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+// promotionWriteError reports a product or category that does not exist as
+// ErrUnknownPromotionTarget instead of a raw foreign key violation.
+func promotionWriteError(err error) error {
+	if _, ok := constraintViolation(err, pqForeignKeyViolation); ok {
+		return ErrUnknownPromotionTarget
+	}
+	return err
+}
+
+func promotionArgs(p models.Promotion) []interface{} {
+	return []interface{}{
+		p.Name, p.Type, p.Percent, p.Amount, p.BuyQty, p.GetQty, p.MinSpend,
+		nullInt(p.ProductID), nullInt(p.CategoryID), nullTime(p.StartsAt), nullTime(p.EndsAt),
+		nullString(p.StartTime), nullString(p.EndTime), p.Active == nil || *p.Active,
+	}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,439 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/internal/audit"
+	"kasir-api/internal/models"
+	"sort"
+)
+
+var (
+	ErrPurchaseOrderNotFound    = errors.New("purchase order not found")
+	ErrPurchaseOrderNotEditable = errors.New("purchase order can no longer be edited once goods are received against it")
+	ErrPurchaseOrderClosed      = errors.New("purchase order has already been received in full or cancelled")
+	ErrLineNotInPurchaseOrder   = errors.New("line does not belong to this purchase order")
+	ErrReceiptQuantityExceeded  = errors.New("received quantity exceeds what is still outstanding on the line")
+)
+
+type PurchaseOrderRepository interface {
+	GetAll(ctx context.Context, filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, int, error)
+	GetByID(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	Create(ctx context.Context, po *models.PurchaseOrder) error
+	Update(ctx context.Context, id int, po *models.PurchaseOrder) error
+	Cancel(ctx context.Context, id int) error
+	Receive(ctx context.Context, id int, req models.GoodsReceiptRequest) error
+	GetOutstanding(ctx context.Context, supplierID int) ([]models.OutstandingSupplier, error)
+}
+
+type postgresPurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPurchaseOrderRepository(db *sql.DB) PurchaseOrderRepository {
+	return &postgresPurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.status, COALESCE(po.note, ''), COALESCE(po.created_by, ''),
+	po.created_at, COALESCE((SELECT SUM(l.quantity * l.unit_cost) FROM purchase_order_lines l WHERE l.purchase_order_id = po.id), 0)`
+
+func scanPurchaseOrder(row interface{ Scan(...interface{}) error }) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.CreatedBy, &po.CreatedAt, &po.TotalAmount)
+	return po, err
+}
+
+func (r *postgresPurchaseOrderRepository) GetAll(ctx context.Context, filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, int, error) {
+	where := " WHERE TRUE"
+	args := []interface{}{}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		where += fmt.Sprintf(" AND po.supplier_id = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += fmt.Sprintf(" AND po.status = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM purchase_orders po"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf("SELECT %s FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id%s ORDER BY po.id DESC LIMIT $%d OFFSET $%d",
+		purchaseOrderColumns, where, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	orders := []models.PurchaseOrder{}
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, po)
+	}
+	return orders, total, rows.Err()
+}
+
+// GetByID returns the order with its lines and every goods receipt posted
+// against it, oldest first.
+func (r *postgresPurchaseOrderRepository) GetByID(ctx context.Context, id int) (*models.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(r.db.QueryRowContext(ctx,
+		"SELECT "+purchaseOrderColumns+" FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id WHERE po.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(product_id, 0), product_name, COALESCE(variant_id, 0), COALESCE(variant_name, ''),
+		       quantity, unit_cost, received_quantity
+		FROM purchase_order_lines
+		WHERE purchase_order_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	po.Lines = []models.PurchaseOrderLine{}
+	for rows.Next() {
+		var l models.PurchaseOrderLine
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.VariantID, &l.VariantName,
+			&l.Quantity, &l.UnitCost, &l.ReceivedQuantity); err != nil {
+			return nil, err
+		}
+		po.Lines = append(po.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	receipts, err := r.db.QueryContext(ctx, `
+		SELECT g.id, COALESCE(g.note, ''), COALESCE(g.created_by, ''), g.created_at,
+		       i.id, i.purchase_order_line_id, i.quantity, i.unit_cost
+		FROM goods_receipts g
+		JOIN goods_receipt_items i ON i.goods_receipt_id = g.id
+		WHERE g.purchase_order_id = $1
+		ORDER BY g.id, i.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer receipts.Close()
+
+	po.Receipts = []models.GoodsReceipt{}
+	for receipts.Next() {
+		var g models.GoodsReceipt
+		var item models.GoodsReceiptItem
+		if err := receipts.Scan(&g.ID, &g.Note, &g.CreatedBy, &g.CreatedAt,
+			&item.ID, &item.PurchaseOrderLineID, &item.Quantity, &item.UnitCost); err != nil {
+			return nil, err
+		}
+		if n := len(po.Receipts); n == 0 || po.Receipts[n-1].ID != g.ID {
+			g.PurchaseOrderID = id
+			po.Receipts = append(po.Receipts, g)
+		}
+		last := &po.Receipts[len(po.Receipts)-1]
+		last.Items = append(last.Items, item)
+	}
+	if err := receipts.Err(); err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+func (r *postgresPurchaseOrderRepository) Create(ctx context.Context, po *models.PurchaseOrder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	po.Status = models.PurchaseOrderOpen
+	po.CreatedBy = audit.User(ctx)
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO purchase_orders (supplier_id, status, note, created_by, created_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at",
+		po.SupplierID, po.Status, nullString(po.Note), nullString(po.CreatedBy),
+	).Scan(&po.ID, &po.CreatedAt)
+	if err != nil {
+		if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "fk_purchase_orders_suppliers" {
+			return ErrUnknownSupplier
+		}
+		return err
+	}
+
+	if err := insertPurchaseOrderLines(ctx, tx, po.ID, po.Lines); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update replaces the supplier, note and lines of an order nothing has been
+// received against yet.
+func (r *postgresPurchaseOrderRepository) Update(ctx context.Context, id int, po *models.PurchaseOrder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderOpen {
+		return ErrPurchaseOrderNotEditable
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE purchase_orders SET supplier_id = $1, note = $2 WHERE id = $3",
+		po.SupplierID, nullString(po.Note), id)
+	if err != nil {
+		if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "fk_purchase_orders_suppliers" {
+			return ErrUnknownSupplier
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM purchase_order_lines WHERE purchase_order_id = $1", id); err != nil {
+		return err
+	}
+	if err := insertPurchaseOrderLines(ctx, tx, id, po.Lines); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertPurchaseOrderLines(ctx context.Context, tx *sql.Tx, id int, lines []models.PurchaseOrderLine) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO purchase_order_lines (purchase_order_id, product_id, product_name, variant_id, variant_name, quantity, unit_cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, l := range lines {
+		_, err := stmt.ExecContext(ctx, id, l.ProductID, l.ProductName, nullInt(l.VariantID), nullString(l.VariantName),
+			l.Quantity, l.UnitCost)
+		if err != nil {
+			if _, ok := constraintViolation(err, pqForeignKeyViolation); ok {
+				return fmt.Errorf("%w: id %d", ErrProductNotFound, l.ProductID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// lockPurchaseOrder locks the order for the rest of tx and returns its
+// status.
+func lockPurchaseOrder(ctx context.Context, tx *sql.Tx, id int) (models.PurchaseOrderStatus, error) {
+	var status models.PurchaseOrderStatus
+	err := tx.QueryRowContext(ctx, "SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrPurchaseOrderNotFound
+	}
+	return status, err
+}
+
+// Cancel closes the order, whatever is still outstanding will not be
+// delivered. Goods already received stay in stock.
+func (r *postgresPurchaseOrderRepository) Cancel(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderOpen && status != models.PurchaseOrderPartiallyReceived {
+		return ErrPurchaseOrderClosed
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE purchase_orders SET status = $1 WHERE id = $2", models.PurchaseOrderCancelled, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Receive posts a goods receipt: the received quantities are added to the
+// lines and to stock through the ledger at the received unit cost, which
+// defaults to the ordered one. The order becomes received once every line is
+// complete.
+func (r *postgresPurchaseOrderRepository) Receive(ctx context.Context, id int, req models.GoodsReceiptRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderOpen && status != models.PurchaseOrderPartiallyReceived {
+		return ErrPurchaseOrderClosed
+	}
+
+	type line struct {
+		productID, variantID  int
+		hasVariant            bool
+		outstanding, unitCost int
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(product_id, 0), COALESCE(variant_id, 0), variant_name IS NOT NULL,
+		       quantity - received_quantity, unit_cost
+		FROM purchase_order_lines
+		WHERE purchase_order_id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	lines := make(map[int]line)
+	for rows.Next() {
+		var lineID int
+		var l line
+		if err := rows.Scan(&lineID, &l.productID, &l.variantID, &l.hasVariant, &l.outstanding, &l.unitCost); err != nil {
+			rows.Close()
+			return err
+		}
+		lines[lineID] = l
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var receiptID int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO goods_receipts (purchase_order_id, note, created_by, created_at) VALUES ($1, $2, $3, NOW()) RETURNING id",
+		id, nullString(req.Note), nullString(audit.User(ctx)),
+	).Scan(&receiptID)
+	if err != nil {
+		return err
+	}
+
+	movements := make([]models.StockMovement, 0, len(req.Items))
+	for _, item := range req.Items {
+		l, ok := lines[item.PurchaseOrderLineID]
+		if !ok {
+			return fmt.Errorf("%w: id %d", ErrLineNotInPurchaseOrder, item.PurchaseOrderLineID)
+		}
+		if item.Quantity > l.outstanding {
+			return fmt.Errorf("%w: line %d has %d outstanding", ErrReceiptQuantityExceeded, item.PurchaseOrderLineID, l.outstanding)
+		}
+		// A line listed twice is checked against what the first took
+		l.outstanding -= item.Quantity
+		lines[item.PurchaseOrderLineID] = l
+		// Nothing to put the stock on once the product or variant is gone
+		if l.productID == 0 || (l.hasVariant && l.variantID == 0) {
+			return fmt.Errorf("%w: purchase order line %d", ErrProductNotFound, item.PurchaseOrderLineID)
+		}
+
+		unitCost := l.unitCost
+		if item.UnitCost != nil {
+			unitCost = *item.UnitCost
+		}
+
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO goods_receipt_items (goods_receipt_id, purchase_order_line_id, quantity, unit_cost) VALUES ($1, $2, $3, $4)",
+			receiptID, item.PurchaseOrderLineID, item.Quantity, unitCost)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE purchase_order_lines SET received_quantity = received_quantity + $1 WHERE id = $2",
+			item.Quantity, item.PurchaseOrderLineID)
+		if err != nil {
+			return err
+		}
+
+		movements = append(movements, models.StockMovement{
+			ProductID:   l.productID,
+			VariantID:   l.variantID,
+			Quantity:    item.Quantity,
+			Reason:      models.StockMovementPurchase,
+			ReferenceID: receiptID,
+			UnitCost:    unitCost,
+			Note:        fmt.Sprintf("purchase order #%d", id),
+		})
+	}
+
+	// Same lock order as checkout
+	sort.Slice(movements, func(i, j int) bool {
+		if movements[i].ProductID != movements[j].ProductID {
+			return movements[i].ProductID < movements[j].ProductID
+		}
+		return movements[i].VariantID < movements[j].VariantID
+	})
+	for i := range movements {
+		if err := moveStock(ctx, tx, &movements[i]); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE purchase_orders SET status = CASE
+			WHEN NOT EXISTS (SELECT 1 FROM purchase_order_lines WHERE purchase_order_id = $1 AND received_quantity < quantity)
+			THEN $2 ELSE $3 END
+		WHERE id = $1`,
+		id, models.PurchaseOrderReceived, models.PurchaseOrderPartiallyReceived)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOutstanding totals what is still to be delivered on open and partially
+// received orders, per supplier and per order. supplierID 0 means every
+// supplier.
+func (r *postgresPurchaseOrderRepository) GetOutstanding(ctx context.Context, supplierID int) ([]models.OutstandingSupplier, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT po.supplier_id, s.name, po.id, po.status, po.created_at,
+		       SUM(l.quantity - l.received_quantity), SUM((l.quantity - l.received_quantity) * l.unit_cost)
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		JOIN purchase_order_lines l ON l.purchase_order_id = po.id
+		WHERE po.status IN ($1, $2) AND ($3::int = 0 OR po.supplier_id = $3::int)
+		GROUP BY po.supplier_id, s.name, po.id
+		HAVING SUM(l.quantity - l.received_quantity) > 0
+		ORDER BY s.name, po.supplier_id, po.id
+	`, models.PurchaseOrderOpen, models.PurchaseOrderPartiallyReceived, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []models.OutstandingSupplier{}
+	for rows.Next() {
+		var sID int
+		var sName string
+		var p models.OutstandingPurchase
+		if err := rows.Scan(&sID, &sName, &p.PurchaseOrderID, &p.Status, &p.CreatedAt,
+			&p.OutstandingQuantity, &p.OutstandingValue); err != nil {
+			return nil, err
+		}
+		if n := len(suppliers); n == 0 || suppliers[n-1].SupplierID != sID {
+			suppliers = append(suppliers, models.OutstandingSupplier{SupplierID: sID, SupplierName: sName})
+		}
+		s := &suppliers[len(suppliers)-1]
+		s.OutstandingQuantity += p.OutstandingQuantity
+		s.OutstandingValue += p.OutstandingValue
+		s.PurchaseOrders = append(s.PurchaseOrders, p)
+	}
+	return suppliers, rows.Err()
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/audit"
+	"kasir-api/internal/models"
+	"strings"
+)
+
+type StockMovementRepository interface {
+	GetAll(ctx context.Context, filter models.StockMovementFilter) ([]models.StockMovement, int, error)
+	Create(ctx context.Context, m *models.StockMovement) error
+}
+
+type postgresStockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockMovementRepository(db *sql.DB) StockMovementRepository {
+	return &postgresStockMovementRepository{db: db}
+}
+
+// GetAll lists stock movements newest first.
+func (r *postgresStockMovementRepository) GetAll(ctx context.Context, filter models.StockMovementFilter) ([]models.StockMovement, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.ProductID != 0 {
+		addCondition("product_id = $%d", filter.ProductID)
+	}
+	if filter.VariantID != 0 {
+		addCondition("variant_id = $%d", filter.VariantID)
+	}
+	if filter.Reason != "" {
+		addCondition("reason = $%d", filter.Reason)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_movements"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(product_id, 0), product_name, COALESCE(variant_id, 0), COALESCE(variant_name, ''), quantity,
+		       balance, reason, COALESCE(reference_id, 0),
+		       COALESCE(unit_cost, 0), COALESCE(note, ''), COALESCE(created_by, ''), created_at
+		FROM stock_movements%s
+		ORDER BY id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.ProductName, &m.VariantID, &m.VariantName, &m.Quantity, &m.Balance,
+			&m.Reason, &m.ReferenceID,
+			&m.UnitCost, &m.Note, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, total, rows.Err()
+}
+
+// Create records a movement posted by hand. A movement that would take the
+// stock below zero fails with ErrInsufficientStock.
+func (r *postgresStockMovementRepository) Create(ctx context.Context, m *models.StockMovement) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := moveStock(ctx, tx, m); err != nil {
+		if _, ok := constraintViolation(err, pqCheckViolation); ok {
+			return ErrInsufficientStock
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// stockCost is what the stock taken out by a movement was worth, at the
+// average cost price and by the FIFO cost layers it came from.
+type stockCost struct {
+	average int
+	fifo    int
+}
+
+// averageCostPrice folds stock coming in at $4 per unit into the average cost
+// price when $3 is set. $1 is the quantity, SET sees the stock before it.
+const averageCostPrice = `CASE WHEN $3 THEN ROUND((stock * cost_price::numeric + $1 * $4::numeric) / (stock + $1))::int ELSE cost_price END`
+
+// moveStock applies m to the stock of its product, and of its variant when it
+// has one, and appends it to the ledger. Every stock change goes through here
+// so the stock columns stay the sum of the ledger. The product row is updated
+// first, the same lock order as checkout.
+func moveStock(ctx context.Context, tx *sql.Tx, m *models.StockMovement) error {
+	_, err := moveStockValued(ctx, tx, m)
+	return err
+}
+
+// moveStockValued is moveStock returning what stock going out was worth.
+//
+// Stock coming in opens a cost layer. Purchases, and refunds of sales with a
+// known cost, come in at m.UnitCost and move the average cost price, anything
+// else comes in at the cost price. Stock going out is taken from the oldest layers first, whatever
+// the layers do not cover is valued at the cost price.
+func moveStockValued(ctx context.Context, tx *sql.Tx, m *models.StockMovement) (stockCost, error) {
+	var cost stockCost
+	costed := m.Quantity > 0 && (m.Reason == models.StockMovementPurchase ||
+		m.Reason == models.StockMovementRefund && m.UnitCost > 0)
+	productCosted := costed && m.VariantID == 0
+
+	var costPrice int
+	err := tx.QueryRowContext(ctx,
+		"UPDATE products SET stock = stock + $1, cost_price = "+averageCostPrice+" WHERE id = $2 RETURNING stock, cost_price, name",
+		m.Quantity, m.ProductID, productCosted, m.UnitCost,
+	).Scan(&m.Balance, &costPrice, &m.ProductName)
+	if err == sql.ErrNoRows {
+		return cost, fmt.Errorf("%w: id %d", ErrProductNotFound, m.ProductID)
+	}
+	if err != nil {
+		return cost, err
+	}
+
+	if m.VariantID != 0 {
+		err := tx.QueryRowContext(ctx,
+			"UPDATE product_variants SET stock = stock + $1, cost_price = "+averageCostPrice+" WHERE id = $2 AND product_id = $5"+
+				" RETURNING stock, cost_price, array_to_string(option_values, ' / ')",
+			m.Quantity, m.VariantID, costed, m.UnitCost, m.ProductID,
+		).Scan(&m.Balance, &costPrice, &m.VariantName)
+		if err == sql.ErrNoRows {
+			return cost, fmt.Errorf("%w: variant id %d", ErrUnknownVariant, m.VariantID)
+		}
+		if err != nil {
+			return cost, err
+		}
+	}
+
+	if m.Quantity > 0 {
+		if !costed {
+			m.UnitCost = costPrice
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO stock_cost_layers (product_id, variant_id, quantity, remaining, unit_cost, created_at) VALUES ($1, $2, $3, $3, $4, NOW())",
+			m.ProductID, nullInt(m.VariantID), m.Quantity, m.UnitCost)
+		if err != nil {
+			return cost, err
+		}
+	} else {
+		cost.average = -m.Quantity * costPrice
+		cost.fifo, err = takeFromLayers(ctx, tx, m.ProductID, m.VariantID, -m.Quantity, costPrice)
+		if err != nil {
+			return cost, err
+		}
+	}
+
+	m.CreatedBy = audit.User(ctx)
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stock_movements (product_id, product_name, variant_id, variant_name, quantity, balance, reason, reference_id,
+			unit_cost, note, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW()) RETURNING id, created_at`,
+		m.ProductID, m.ProductName, nullInt(m.VariantID), nullString(m.VariantName), m.Quantity, m.Balance, m.Reason, nullInt(m.ReferenceID),
+		sql.NullInt64{Int64: int64(m.UnitCost), Valid: m.Quantity > 0}, nullString(m.Note), nullString(m.CreatedBy),
+	).Scan(&m.ID, &m.CreatedAt)
+	return cost, err
+}
+
+// takeFromLayers uses up qty units of the oldest cost layers of a product, or
+// of one of its variants, and returns what they cost. Units beyond the layers
+// are valued at costPrice.
+func takeFromLayers(ctx context.Context, tx *sql.Tx, productID, variantID, qty, costPrice int) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, remaining, unit_cost
+		FROM stock_cost_layers
+		WHERE product_id = $1 AND variant_id IS NOT DISTINCT FROM $2 AND remaining > 0
+		ORDER BY id
+		FOR UPDATE`,
+		productID, nullInt(variantID))
+	if err != nil {
+		return 0, err
+	}
+	type take struct {
+		id       int64
+		quantity int
+	}
+	var takes []take
+	cost := 0
+	for qty > 0 && rows.Next() {
+		var t take
+		var remaining, unitCost int
+		if err := rows.Scan(&t.id, &remaining, &unitCost); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		t.quantity = min(remaining, qty)
+		qty -= t.quantity
+		cost += t.quantity * unitCost
+		takes = append(takes, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, t := range takes {
+		if _, err := tx.ExecContext(ctx, "UPDATE stock_cost_layers SET remaining = remaining - $1 WHERE id = $2", t.quantity, t.id); err != nil {
+			return 0, err
+		}
+	}
+	return cost + qty*costPrice, nil
+}
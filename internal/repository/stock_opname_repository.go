@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/internal/audit"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrStockOpnameNotFound     = errors.New("stock opname not found")
+	ErrStockOpnameAlreadyOpen  = errors.New("another stock opname of the same products is still open, post or cancel it first")
+	ErrStockOpnameClosed       = errors.New("stock opname has already been posted or cancelled")
+	ErrStockOpnameItemNotFound = errors.New("product or variant is not part of the stock opname")
+)
+
+type StockOpnameRepository interface {
+	GetAll(ctx context.Context, filter models.StockOpnameFilter) ([]models.StockOpname, int, error)
+	GetByID(ctx context.Context, id int) (*models.StockOpname, error)
+	Create(ctx context.Context, o *models.StockOpname) error
+	SetCounts(ctx context.Context, id int, counts []models.StockCount) error
+	Post(ctx context.Context, id int) error
+	Cancel(ctx context.Context, id int) error
+}
+
+type postgresStockOpnameRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockOpnameRepository(db *sql.DB) StockOpnameRepository {
+	return &postgresStockOpnameRepository{db: db}
+}
+
+const stockOpnameColumns = `id, status, COALESCE(category_id, 0), COALESCE(note, ''), COALESCE(created_by, ''), created_at,
+	COALESCE(closed_by, ''), closed_at`
+
+func scanStockOpname(row interface{ Scan(...interface{}) error }) (models.StockOpname, error) {
+	var o models.StockOpname
+	var closedAt sql.NullTime
+	err := row.Scan(&o.ID, &o.Status, &o.CategoryID, &o.Note, &o.CreatedBy, &o.CreatedAt, &o.ClosedBy, &closedAt)
+	if closedAt.Valid {
+		o.ClosedAt = &closedAt.Time
+	}
+	return o, err
+}
+
+func (r *postgresStockOpnameRepository) GetAll(ctx context.Context, filter models.StockOpnameFilter) ([]models.StockOpname, int, error) {
+	where := ""
+	args := []interface{}{}
+	if filter.Status != "" {
+		where = " WHERE status = $1"
+		args = append(args, filter.Status)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_opnames"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf("SELECT %s FROM stock_opnames%s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+		stockOpnameColumns, where, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	opnames := []models.StockOpname{}
+	for rows.Next() {
+		o, err := scanStockOpname(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		opnames = append(opnames, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.loadSummaries(ctx, opnames); err != nil {
+		return nil, 0, err
+	}
+	return opnames, total, nil
+}
+
+// GetByID returns the session with its lines, ordered by product and
+// variant.
+func (r *postgresStockOpnameRepository) GetByID(ctx context.Context, id int) (*models.StockOpname, error) {
+	o, err := scanStockOpname(r.db.QueryRowContext(ctx, "SELECT "+stockOpnameColumns+" FROM stock_opnames WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStockOpnameNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, product_id, product_name, COALESCE(variant_id, 0), COALESCE(variant_name, ''), expected, counted,
+		       unit_cost, COALESCE(counted_by, ''), counted_at
+		FROM stock_opname_items
+		WHERE opname_id = $1
+		ORDER BY product_id, variant_id NULLS FIRST
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	o.Items = []models.StockOpnameItem{}
+	for rows.Next() {
+		var item models.StockOpnameItem
+		var counted sql.NullInt64
+		var countedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName, &item.Expected,
+			&counted, &item.UnitCost, &item.CountedBy, &countedAt); err != nil {
+			return nil, err
+		}
+		if counted.Valid {
+			c := int(counted.Int64)
+			variance := c - item.Expected
+			value := variance * item.UnitCost
+			item.Counted, item.Variance, item.VarianceValue = &c, &variance, &value
+		}
+		if countedAt.Valid {
+			item.CountedAt = &countedAt.Time
+		}
+		o.Items = append(o.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	opnames := []models.StockOpname{o}
+	if err := r.loadSummaries(ctx, opnames); err != nil {
+		return nil, err
+	}
+	return &opnames[0], nil
+}
+
+// loadSummaries totals the lines of the given sessions with one query.
+func (r *postgresStockOpnameRepository) loadSummaries(ctx context.Context, opnames []models.StockOpname) error {
+	if len(opnames) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*models.StockOpname, len(opnames))
+	ids := make([]int, 0, len(opnames))
+	for i := range opnames {
+		index[opnames[i].ID] = &opnames[i]
+		ids = append(ids, opnames[i].ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT opname_id, COUNT(*), COUNT(counted),
+		       COALESCE(SUM(counted - expected), 0),
+		       COALESCE(SUM((counted - expected) * unit_cost), 0),
+		       COALESCE(SUM((expected - counted) * unit_cost) FILTER (WHERE counted < expected), 0),
+		       COALESCE(SUM((counted - expected) * unit_cost) FILTER (WHERE counted > expected), 0)
+		FROM stock_opname_items
+		WHERE opname_id = ANY($1)
+		GROUP BY opname_id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var s models.StockOpnameSummary
+		if err := rows.Scan(&id, &s.Items, &s.Counted, &s.VarianceQuantity, &s.VarianceValue, &s.ShortageValue, &s.SurplusValue); err != nil {
+			return err
+		}
+		index[id].Summary = s
+	}
+	return rows.Err()
+}
+
+// Create opens a session and freezes the expected quantities. The snapshot
+// is taken in a repeatable read transaction so every line is read at the same
+// point in time.
+func (r *postgresStockOpnameRepository) Create(ctx context.Context, o *models.StockOpname) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Sessions may run side by side as long as they count different
+	// products: one per category, and a whole catalogue count alone. The lock
+	// serialises opening sessions, and comes before the snapshot is taken.
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE stock_opnames IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var overlaps bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM stock_opnames
+			WHERE status = 'open' AND ($1::int = 0 OR category_id IS NULL OR category_id = $1::int)
+		)`, o.CategoryID).Scan(&overlaps)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return ErrStockOpnameAlreadyOpen
+	}
+
+	o.Status = models.StockOpnameOpen
+	o.CreatedBy = audit.User(ctx)
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO stock_opnames (status, category_id, note, created_by, created_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at",
+		o.Status, nullInt(o.CategoryID), nullString(o.Note), nullString(o.CreatedBy),
+	).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		if constraint, ok := constraintViolation(err, pqUniqueViolation); ok && constraint == "idx_stock_opnames_open" {
+			return ErrStockOpnameAlreadyOpen
+		}
+		if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "stock_opnames_category_id_fkey" {
+			return ErrUnknownCategory
+		}
+		return err
+	}
+
+	// Products with variants are counted per variant, variant names are
+	// built like models.VariantName. Lines are valued at their cost price
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO stock_opname_items (opname_id, product_id, product_name, variant_id, variant_name, expected, unit_cost)
+		SELECT $1::int, p.id, p.name, NULL, NULL, p.stock, p.cost_price
+		FROM products p
+		WHERE ($2::int = 0 OR p.category_id = $2::int)
+		  AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+		UNION ALL
+		SELECT $1::int, p.id, p.name, v.id, array_to_string(v.option_values, ' / '), v.stock, v.cost_price
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE ($2::int = 0 OR p.category_id = $2::int)
+	`, o.ID, o.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	o.Items = nil
+	return nil
+}
+
+// lockOpen locks an open session for the rest of tx.
+func lockOpen(ctx context.Context, tx *sql.Tx, id int) error {
+	var status models.StockOpnameStatus
+	err := tx.QueryRowContext(ctx, "SELECT status FROM stock_opnames WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrStockOpnameNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.StockOpnameOpen {
+		return ErrStockOpnameClosed
+	}
+	return nil
+}
+
+func (r *postgresStockOpnameRepository) SetCounts(ctx context.Context, id int, counts []models.StockCount) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpen(ctx, tx, id); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE stock_opname_items SET counted = $1, counted_by = $2, counted_at = NOW()
+		WHERE opname_id = $3 AND product_id = $4 AND COALESCE(variant_id, 0) = $5`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	user := nullString(audit.User(ctx))
+	for _, c := range counts {
+		result, err := stmt.ExecContext(ctx, c.Counted, user, id, c.ProductID, c.VariantID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: product id %d, variant id %d", ErrStockOpnameItemNotFound, c.ProductID, c.VariantID)
+		}
+	}
+	return tx.Commit()
+}
+
+// Post adjusts the stock of every counted line with a variance and closes the
+// session. Lines are adjusted in product order, the lock order of checkout.
+func (r *postgresStockOpnameRepository) Post(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpen(ctx, tx, id); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT product_id, COALESCE(variant_id, 0), counted - expected
+		FROM stock_opname_items
+		WHERE opname_id = $1 AND counted IS NOT NULL AND counted <> expected
+		ORDER BY product_id, variant_id NULLS FIRST
+	`, id)
+	if err != nil {
+		return err
+	}
+	var movements []models.StockMovement
+	for rows.Next() {
+		m := models.StockMovement{
+			Reason:      models.StockMovementAdjustment,
+			ReferenceID: id,
+			Note:        fmt.Sprintf("stock opname #%d", id),
+		}
+		if err := rows.Scan(&m.ProductID, &m.VariantID, &m.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		movements = append(movements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range movements {
+		if err := moveStock(ctx, tx, &movements[i]); err != nil {
+			// More was sold while counting than the count leaves
+			if _, ok := constraintViolation(err, pqCheckViolation); ok {
+				return fmt.Errorf("%w: product id %d", ErrInsufficientStock, movements[i].ProductID)
+			}
+			return err
+		}
+	}
+
+	if err := closeOpname(ctx, tx, id, models.StockOpnamePosted); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresStockOpnameRepository) Cancel(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpen(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := closeOpname(ctx, tx, id, models.StockOpnameCancelled); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func closeOpname(ctx context.Context, tx *sql.Tx, id int, status models.StockOpnameStatus) error {
+	_, err := tx.ExecContext(ctx, "UPDATE stock_opnames SET status = $1, closed_by = $2, closed_at = NOW() WHERE id = $3",
+		status, nullString(audit.User(ctx)), id)
+	return err
+}
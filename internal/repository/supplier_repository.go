@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"kasir-api/internal/models"
+)
+
+var (
+	ErrSupplierNotFound = errors.New("supplier not found")
+	ErrSupplierInUse    = errors.New("supplier still has purchase orders")
+	ErrUnknownSupplier  = errors.New("supplier_id does not refer to an existing supplier")
+)
+
+type SupplierRepository interface {
+	GetAll(ctx context.Context) ([]models.Supplier, error)
+	GetByID(ctx context.Context, id int) (*models.Supplier, error)
+	Create(ctx context.Context, s models.Supplier) (*models.Supplier, error)
+	Update(ctx context.Context, id int, s models.Supplier) (*models.Supplier, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type postgresSupplierRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSupplierRepository(db *sql.DB) SupplierRepository {
+	return &postgresSupplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, COALESCE(contact_name, ''), COALESCE(phone, ''), COALESCE(email, ''), COALESCE(address, '')"
+
+func scanSupplier(row interface{ Scan(...interface{}) error }) (models.Supplier, error) {
+	var s models.Supplier
+	err := row.Scan(&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address)
+	return s, err
+}
+
+func (r *postgresSupplierRepository) GetAll(ctx context.Context) ([]models.Supplier, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+supplierColumns+" FROM suppliers ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []models.Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, rows.Err()
+}
+
+func (r *postgresSupplierRepository) GetByID(ctx context.Context, id int) (*models.Supplier, error) {
+	s, err := scanSupplier(r.db.QueryRowContext(ctx, "SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *postgresSupplierRepository) Create(ctx context.Context, s models.Supplier) (*models.Supplier, error) {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO suppliers (name, contact_name, phone, email, address) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		s.Name, nullString(s.ContactName), nullString(s.Phone), nullString(s.Email), nullString(s.Address),
+	).Scan(&s.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *postgresSupplierRepository) Update(ctx context.Context, id int, s models.Supplier) (*models.Supplier, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5 WHERE id = $6",
+		s.Name, nullString(s.ContactName), nullString(s.Phone), nullString(s.Email), nullString(s.Address), id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrSupplierNotFound
+	}
+
+	s.ID = id
+	return &s, nil
+}
+
+func (r *postgresSupplierRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM suppliers WHERE id = $1", id)
+	if err != nil {
+		if constraint, ok := constraintViolation(err, pqForeignKeyViolation); ok && constraint == "fk_purchase_orders_suppliers" {
+			return ErrSupplierInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrSupplierNotFound
+	}
+
+	return nil
+}
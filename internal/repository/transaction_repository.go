@@ -1,61 +1,706 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"kasir-api/internal/models"
+	"math"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInsufficientStock       = errors.New("insufficient stock")
+	ErrTransactionNotFound     = errors.New("transaction not found")
+	ErrDuplicateIdempotencyKey = errors.New("idempotency key already used")
+	ErrTransactionVoided       = errors.New("transaction has been voided")
+	ErrNothingToRefund         = errors.New("every item of the transaction has already been refunded")
+	ErrDetailNotInTransaction  = errors.New("transaction detail does not belong to the transaction")
+	ErrRefundQuantityExceeded  = errors.New("refund quantity exceeds the quantity left to refund")
 )
 
+// InsufficientStockError lists every checkout line that cannot be fulfilled
+// with the stock available at the time the product rows were locked.
+type InsufficientStockError struct {
+	Items []models.StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		name := item.ProductName
+		if item.VariantName != "" {
+			name += " " + item.VariantName
+		}
+		parts = append(parts, fmt.Sprintf("%s (requested %d, available %d)", name, item.Requested, item.Available))
+	}
+	return "insufficient stock for product " + strings.Join(parts, ", ")
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
 type TransactionRepository interface {
-	CreateTransaction(transaction *models.Transaction) error
-	GetSalesSummary(startDate, endDate time.Time) (models.SalesReport, error)
+	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+	GetAll(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, int, error)
+	Each(ctx context.Context, filter models.TransactionFilter, fn func(models.Transaction) error) error
+	GetByID(ctx context.Context, id int) (*models.Transaction, error)
+	GetByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error)
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	GetSalesSummary(ctx context.Context, startDate, endDate time.Time, opts models.ReportOptions) (models.SalesReport, error)
 }
 
 type postgresTransactionRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	costing models.CostingMethod
 }
 
-func NewPostgresTransactionRepository(db *sql.DB) TransactionRepository {
-	return &postgresTransactionRepository{db: db}
+// NewPostgresTransactionRepository returns the repository, costing decides
+// what the cost of a sale is.
+func NewPostgresTransactionRepository(db *sql.DB, costing models.CostingMethod) TransactionRepository {
+	return &postgresTransactionRepository{db: db, costing: costing}
 }
 
-func (r *postgresTransactionRepository) CreateTransaction(t *models.Transaction) error {
-	tx, err := r.db.Begin()
+func (r *postgresTransactionRepository) CreateTransaction(ctx context.Context, t *models.Transaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Insert Transaction
-	query := `INSERT INTO transactions (total_amount, created_at) VALUES ($1, NOW()) RETURNING id, created_at`
-	err = tx.QueryRow(query, t.TotalAmount).Scan(&t.ID, &t.CreatedAt)
+	// Insert Transaction first: a concurrent retry with the same idempotency
+	// key blocks on the unique index here and fails once we commit, instead of
+	// competing for the stock this transaction is about to take.
+	query := `
+		INSERT INTO transactions (gross_amount, discount_amount, cart_promotion_id, cart_promotion_name, subtotal, tax_amount,
+			service_charge, total_amount, paid_amount, change_amount, idempotency_key, request_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW()) RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query,
+		t.GrossAmount, t.DiscountAmount, nullInt(t.CartPromotionID), nullString(t.CartPromotion), t.Subtotal, t.TaxAmount,
+		t.ServiceCharge, t.TotalAmount, t.PaidAmount, t.Change, nullString(t.IdempotencyKey), nullString(t.RequestHash),
+	).Scan(&t.ID, &t.CreatedAt)
 	if err != nil {
+		if constraint, ok := constraintViolation(err, pqUniqueViolation); ok && constraint == "idx_transactions_idempotency_key" {
+			return ErrDuplicateIdempotencyKey
+		}
+		if businessDayClosed(err) {
+			return ErrBusinessDayClosed
+		}
+		return err
+	}
+
+	// Lock and validate stock before any detail is written
+	if err := lockStock(ctx, tx, t.Details); err != nil {
 		return err
 	}
 
 	// Insert Details
-	detailQuery := `INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)`
-	stmt, err := tx.Prepare(detailQuery)
+	detailQuery := `
+		INSERT INTO transaction_details (transaction_id, product_id, product_name, variant_id, variant_name, category_id, category_name,
+			unit_price, quantity, discount_amount, promotion_id, promotion_name, subtotal, tax_rate, tax_inclusive, tax_base, tax_amount,
+			service_charge, unit_cost, cost_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20) RETURNING id`
+	stmt, err := tx.PrepareContext(ctx, detailQuery)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	// Update Stock
-	updateStockQuery := `UPDATE products SET stock = stock - $1 WHERE id = $2`
-	updateStockStmt, err := tx.Prepare(updateStockQuery)
+	for i := range t.Details {
+		detail := &t.Details[i]
+		detail.TransactionID = t.ID
+
+		// Stock goes first, the line keeps what it cost
+		cost, err := moveStockValued(ctx, tx, &models.StockMovement{
+			ProductID:   detail.ProductID,
+			VariantID:   detail.VariantID,
+			Quantity:    -detail.Quantity,
+			Reason:      models.StockMovementSale,
+			ReferenceID: t.ID,
+		})
+		if err != nil {
+			// The stock check constraints are the last line of defence
+			if _, ok := constraintViolation(err, pqCheckViolation); ok {
+				return ErrInsufficientStock
+			}
+			return err
+		}
+		detail.CostAmount = cost.average
+		if r.costing == models.CostingFIFO {
+			detail.CostAmount = cost.fifo
+		}
+		detail.UnitCost = (detail.CostAmount + detail.Quantity/2) / detail.Quantity
+
+		err = stmt.QueryRowContext(ctx,
+			t.ID, detail.ProductID, detail.ProductName, nullInt(detail.VariantID), nullString(detail.VariantName),
+			nullInt(detail.CategoryID), nullString(detail.CategoryName), detail.UnitPrice, detail.Quantity, detail.DiscountAmount, nullInt(detail.PromotionID), nullString(detail.PromotionName),
+			detail.Subtotal, detail.TaxRate, detail.TaxInclusive, detail.TaxBase, detail.TaxAmount, detail.ServiceCharge,
+			detail.UnitCost, detail.CostAmount,
+		).Scan(&detail.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Insert Payments
+	paymentStmt, err := tx.PrepareContext(ctx, `INSERT INTO payments (transaction_id, method, amount, reference) VALUES ($1, $2, $3, $4) RETURNING id`)
+	if err != nil {
+		return err
+	}
+	defer paymentStmt.Close()
+
+	for i := range t.Payments {
+		payment := &t.Payments[i]
+		payment.TransactionID = t.ID
+		err = paymentStmt.QueryRowContext(ctx, t.ID, payment.Method, payment.Amount, nullString(payment.Reference)).Scan(&payment.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	t.Status = models.TransactionStatusCompleted
+	t.Refunds = []models.Refund{}
+	return nil
+}
+
+const transactionColumns = `t.id, t.gross_amount, t.discount_amount, COALESCE(t.cart_promotion_id, 0), COALESCE(t.cart_promotion_name, ''),
+	t.subtotal, t.tax_amount, t.service_charge, t.total_amount, t.paid_amount, t.change_amount,
+	COALESCE(t.idempotency_key, ''), COALESCE(t.request_hash, ''), t.created_at`
+
+func scanTransaction(row interface{ Scan(...interface{}) error }) (models.Transaction, error) {
+	var t models.Transaction
+	err := row.Scan(&t.ID, &t.GrossAmount, &t.DiscountAmount, &t.CartPromotionID, &t.CartPromotion,
+		&t.Subtotal, &t.TaxAmount, &t.ServiceCharge, &t.TotalAmount, &t.PaidAmount, &t.Change,
+		&t.IdempotencyKey, &t.RequestHash, &t.CreatedAt)
+	return t, err
+}
+
+func (r *postgresTransactionRepository) GetByID(ctx context.Context, id int) (*models.Transaction, error) {
+	return r.getOne(ctx, "t.id = $1", id)
+}
+
+func (r *postgresTransactionRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error) {
+	return r.getOne(ctx, "t.idempotency_key = $1", key)
+}
+
+func (r *postgresTransactionRepository) getOne(ctx context.Context, where string, arg interface{}) (*models.Transaction, error) {
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, "SELECT "+transactionColumns+" FROM transactions t WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	transactions := []models.Transaction{t}
+	if err := r.loadLines(ctx, transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// transactionConditions turns the filter into WHERE conditions on
+// transactions t and their arguments, numbered from $1.
+func transactionConditions(filter models.TransactionFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.StartDate != nil {
+		addCondition("t.created_at >= $%d", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		addCondition("t.created_at < $%d", *filter.EndDate)
+	}
+	if filter.MinAmount != nil {
+		addCondition("t.total_amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("t.total_amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.ProductID != 0 {
+		addCondition("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = t.id AND td.product_id = $%d)", filter.ProductID)
+	}
+	return conditions, args
+}
+
+func (r *postgresTransactionRepository) GetAll(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, int, error) {
+	conditions, args := transactionConditions(filter)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions t"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+	query := fmt.Sprintf(
+		"SELECT %s FROM transactions t%s ORDER BY t.created_at DESC, t.id DESC LIMIT $%d OFFSET $%d",
+		transactionColumns, where, len(args)-1, len(args),
+	)
+	transactions, err := r.queryTransactions(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.loadLines(ctx, transactions); err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// eachBatchSize is how many transactions Each loads at a time.
+const eachBatchSize = 500
+
+// Each calls fn for every transaction matching the filter, newest first,
+// ignoring the paging. Transactions are loaded in batches that continue after
+// the last one seen rather than at an offset, so the whole table can be walked
+// without holding it in memory or slowing down towards the end.
+func (r *postgresTransactionRepository) Each(ctx context.Context, filter models.TransactionFilter, fn func(models.Transaction) error) error {
+	var after *models.Transaction
+	for {
+		conditions, args := transactionConditions(filter)
+		if after != nil {
+			args = append(args, after.CreatedAt, after.ID)
+			conditions = append(conditions, fmt.Sprintf("(t.created_at, t.id) < ($%d, $%d)", len(args)-1, len(args)))
+		}
+		where := ""
+		if len(conditions) > 0 {
+			where = " WHERE " + strings.Join(conditions, " AND ")
+		}
+
+		args = append(args, eachBatchSize)
+		query := fmt.Sprintf("SELECT %s FROM transactions t%s ORDER BY t.created_at DESC, t.id DESC LIMIT $%d",
+			transactionColumns, where, len(args))
+		transactions, err := r.queryTransactions(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if err := r.loadLines(ctx, transactions); err != nil {
+			return err
+		}
+
+		for _, t := range transactions {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		if len(transactions) < eachBatchSize {
+			return nil
+		}
+		after = &transactions[len(transactions)-1]
+	}
+}
+
+func (r *postgresTransactionRepository) queryTransactions(ctx context.Context, query string, args ...interface{}) ([]models.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// loadLines fills in the details and payments of the given transactions with
+// one query each, regardless of how many transactions there are.
+func (r *postgresTransactionRepository) loadLines(ctx context.Context, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*models.Transaction, len(transactions))
+	ids := make([]int, 0, len(transactions))
+	for i := range transactions {
+		transactions[i].Details = []models.TransactionDetail{}
+		transactions[i].Payments = []models.Payment{}
+		index[transactions[i].ID] = &transactions[i]
+		ids = append(ids, transactions[i].ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT td.id, td.transaction_id, COALESCE(td.product_id, 0), td.product_name,
+		       COALESCE(td.variant_id, 0), COALESCE(td.variant_name, ''), COALESCE(td.category_id, 0), COALESCE(td.category_name, ''), td.unit_price, td.quantity,
+		       td.discount_amount, COALESCE(td.promotion_id, 0), COALESCE(td.promotion_name, ''), td.subtotal,
+		       td.tax_rate, td.tax_inclusive, td.tax_base, td.tax_amount, td.service_charge, td.unit_cost, td.cost_amount
+		FROM transaction_details td
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY td.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.TransactionDetail
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName,
+			&d.VariantID, &d.VariantName, &d.CategoryID, &d.CategoryName, &d.UnitPrice, &d.Quantity,
+			&d.DiscountAmount, &d.PromotionID, &d.PromotionName, &d.Subtotal,
+			&d.TaxRate, &d.TaxInclusive, &d.TaxBase, &d.TaxAmount, &d.ServiceCharge, &d.UnitCost, &d.CostAmount); err != nil {
+			return err
+		}
+		t := index[d.TransactionID]
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	paymentRows, err := r.db.QueryContext(ctx, `
+		SELECT id, transaction_id, method, amount, COALESCE(reference, '')
+		FROM payments
+		WHERE transaction_id = ANY($1)
+		ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer paymentRows.Close()
+
+	for paymentRows.Next() {
+		var p models.Payment
+		if err := paymentRows.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.Reference); err != nil {
+			return err
+		}
+		t := index[p.TransactionID]
+		t.Payments = append(t.Payments, p)
+	}
+	if err := paymentRows.Err(); err != nil {
+		return err
+	}
+
+	return r.loadRefunds(ctx, index, ids)
+}
+
+func (r *postgresTransactionRepository) loadRefunds(ctx context.Context, index map[int]*models.Transaction, ids []int) error {
+	for _, t := range index {
+		t.Refunds = []models.Refund{}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, transaction_id, type, reason, amount, created_at
+		FROM refunds
+		WHERE transaction_id = ANY($1)
+		ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	refunds := make(map[int]*models.Refund)
+	var order []int
+	for rows.Next() {
+		rf := &models.Refund{Items: []models.RefundItem{}}
+		if err := rows.Scan(&rf.ID, &rf.TransactionID, &rf.Type, &rf.Reason, &rf.Amount, &rf.CreatedAt); err != nil {
+			return err
+		}
+		refunds[rf.ID] = rf
+		order = append(order, rf.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(order) > 0 {
+		itemRows, err := r.db.QueryContext(ctx, `
+			SELECT id, refund_id, transaction_detail_id, COALESCE(product_id, 0), quantity, amount, tax_base, tax_amount,
+			       service_charge, cost_amount
+			FROM refund_items
+			WHERE refund_id = ANY($1)
+			ORDER BY id
+		`, pq.Array(order))
+		if err != nil {
+			return err
+		}
+		defer itemRows.Close()
+
+		for itemRows.Next() {
+			var item models.RefundItem
+			if err := itemRows.Scan(&item.ID, &item.RefundID, &item.TransactionDetailID, &item.ProductID, &item.Quantity,
+				&item.Amount, &item.TaxBase, &item.TaxAmount, &item.ServiceCharge, &item.CostAmount); err != nil {
+				return err
+			}
+			rf := refunds[item.RefundID]
+			rf.Items = append(rf.Items, item)
+		}
+		if err := itemRows.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range order {
+		rf := refunds[id]
+		t := index[rf.TransactionID]
+		t.Refunds = append(t.Refunds, *rf)
+	}
+	for _, t := range index {
+		t.Status, t.RefundedAmount = transactionStatus(t)
+	}
+	return nil
+}
+
+// transactionStatus derives the status of a transaction from its refunds.
+// The transaction row itself is never updated once written.
+func transactionStatus(t *models.Transaction) (string, int) {
+	var refundedAmount, refundedQty, soldQty int
+	voided := false
+	for _, rf := range t.Refunds {
+		refundedAmount += rf.Amount
+		if rf.Type == models.RefundTypeVoid {
+			voided = true
+		}
+		for _, item := range rf.Items {
+			refundedQty += item.Quantity
+		}
+	}
+	for _, d := range t.Details {
+		soldQty += d.Quantity
+	}
+
+	switch {
+	case voided:
+		return models.TransactionStatusVoided, refundedAmount
+	case refundedQty > 0 && refundedQty >= soldQty:
+		return models.TransactionStatusRefunded, refundedAmount
+	case refundedQty > 0:
+		return models.TransactionStatusPartiallyRefunded, refundedAmount
+	}
+	return models.TransactionStatusCompleted, refundedAmount
+}
+
+// refundableLine is a transaction detail with what has been refunded of it
+// so far. charged is what the customer paid for the line: its subtotal plus
+// exclusive tax and its share of the service charge.
+type refundableLine struct {
+	productID         sql.NullInt64
+	variantID         sql.NullInt64
+	variantGone       bool
+	quantity          int
+	charged           int
+	taxBase           int
+	taxAmount         int
+	serviceCharge     int
+	costAmount        int
+	refundedQty       int
+	refundedAmount    int
+	refundedTaxBase   int
+	refundedTaxAmount int
+	refundedCharge    int
+	refundedCost      int
+}
+
+// prorate returns the part of total that belongs to qty of the line's units.
+// The last units of a line take whatever is left of total, so the refunds of
+// a line always add up to what was charged for it exactly.
+func (l *refundableLine) prorate(total, refunded, qty int) int {
+	if l.refundedQty+qty == l.quantity {
+		return total - refunded
+	}
+	return total * qty / l.quantity
+}
+
+// CreateRefund records a void or a partial refund and puts the returned
+// quantities back into stock. For a void, refund.Items is ignored and every
+// quantity not yet refunded is returned. Amounts, tax base and tax are
+// prorated from the line.
+func (r *postgresTransactionRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serialise refunds of the same transaction
+	var id int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM transactions WHERE id = $1 FOR UPDATE", refund.TransactionID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTransactionNotFound
+		}
+		return err
+	}
+
+	var voided bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM refunds WHERE transaction_id = $1 AND type = 'void')", refund.TransactionID).Scan(&voided)
+	if err != nil {
+		return err
+	}
+	if voided {
+		return ErrTransactionVoided
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT td.id, td.product_id, td.variant_id, td.variant_id IS NULL AND td.variant_name IS NOT NULL, td.quantity,
+		       td.subtotal + td.service_charge + CASE WHEN td.tax_inclusive THEN 0 ELSE td.tax_amount END,
+		       td.tax_base, td.tax_amount, td.service_charge, td.cost_amount,
+		       COALESCE(SUM(ri.quantity), 0), COALESCE(SUM(ri.amount), 0), COALESCE(SUM(ri.tax_base), 0),
+		       COALESCE(SUM(ri.tax_amount), 0), COALESCE(SUM(ri.service_charge), 0), COALESCE(SUM(ri.cost_amount), 0)
+		FROM transaction_details td
+		LEFT JOIN refund_items ri ON ri.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		GROUP BY td.id
+		ORDER BY td.id
+	`, refund.TransactionID)
+	if err != nil {
+		return err
+	}
+	lines := make(map[int]*refundableLine)
+	var lineIDs []int
+	for rows.Next() {
+		var detailID int
+		line := &refundableLine{}
+		if err := rows.Scan(&detailID, &line.productID, &line.variantID, &line.variantGone, &line.quantity, &line.charged, &line.taxBase, &line.taxAmount, &line.serviceCharge,
+			&line.costAmount, &line.refundedQty, &line.refundedAmount, &line.refundedTaxBase, &line.refundedTaxAmount, &line.refundedCharge,
+			&line.refundedCost); err != nil {
+			rows.Close()
+			return err
+		}
+		lines[detailID] = line
+		lineIDs = append(lineIDs, detailID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	requested := make(map[int]int)
+	var order []int
+	if refund.Type == models.RefundTypeVoid {
+		for _, detailID := range lineIDs {
+			if left := lines[detailID].quantity - lines[detailID].refundedQty; left > 0 {
+				requested[detailID] = left
+				order = append(order, detailID)
+			}
+		}
+		if len(order) == 0 {
+			return ErrNothingToRefund
+		}
+	} else {
+		for _, item := range refund.Items {
+			line, ok := lines[item.TransactionDetailID]
+			if !ok {
+				return fmt.Errorf("%w: detail id %d", ErrDetailNotInTransaction, item.TransactionDetailID)
+			}
+			if _, seen := requested[item.TransactionDetailID]; !seen {
+				order = append(order, item.TransactionDetailID)
+			}
+			requested[item.TransactionDetailID] += item.Quantity
+			if requested[item.TransactionDetailID] > line.quantity-line.refundedQty {
+				return fmt.Errorf("%w: detail id %d", ErrRefundQuantityExceeded, item.TransactionDetailID)
+			}
+		}
+	}
+
+	refund.Items = make([]models.RefundItem, 0, len(order))
+	refund.Amount = 0
+	for _, detailID := range order {
+		line := lines[detailID]
+		qty := requested[detailID]
+		item := models.RefundItem{
+			TransactionDetailID: detailID,
+			ProductID:           int(line.productID.Int64),
+			Quantity:            qty,
+			Amount:              line.prorate(line.charged, line.refundedAmount, qty),
+			TaxBase:             line.prorate(line.taxBase, line.refundedTaxBase, qty),
+			TaxAmount:           line.prorate(line.taxAmount, line.refundedTaxAmount, qty),
+			ServiceCharge:       line.prorate(line.serviceCharge, line.refundedCharge, qty),
+			CostAmount:          line.prorate(line.costAmount, line.refundedCost, qty),
+		}
+		refund.Items = append(refund.Items, item)
+		refund.Amount += item.Amount
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO refunds (transaction_id, type, reason, amount, created_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at",
+		refund.TransactionID, refund.Type, refund.Reason, refund.Amount,
+	).Scan(&refund.ID, &refund.CreatedAt)
 	if err != nil {
+		if _, ok := constraintViolation(err, pqUniqueViolation); ok {
+			return ErrTransactionVoided
+		}
+		if businessDayClosed(err) {
+			return ErrBusinessDayClosed
+		}
 		return err
 	}
-	defer updateStockStmt.Close()
 
-	for _, detail := range t.Details {
-		_, err = stmt.Exec(t.ID, detail.ProductID, detail.Quantity, detail.Subtotal)
+	itemStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO refund_items (refund_id, transaction_detail_id, product_id, quantity, amount, tax_base, tax_amount,
+			service_charge, cost_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`)
+	if err != nil {
+		return err
+	}
+	defer itemStmt.Close()
+
+	// Returned units go back in at what they cost when sold
+	type stockLine struct{ product, variant int }
+	type returned struct{ quantity, cost int }
+	restock := make(map[stockLine]returned)
+	for i := range refund.Items {
+		item := &refund.Items[i]
+		item.RefundID = refund.ID
+		line := lines[item.TransactionDetailID]
+		err = itemStmt.QueryRowContext(ctx, refund.ID, item.TransactionDetailID, line.productID, item.Quantity,
+			item.Amount, item.TaxBase, item.TaxAmount, item.ServiceCharge, item.CostAmount).Scan(&item.ID)
 		if err != nil {
 			return err
 		}
 
-		_, err = updateStockStmt.Exec(detail.Quantity, detail.ProductID)
+		// The product or variant may have been deleted since the sale, nothing
+		// to restock then
+		if line.productID.Valid && !line.variantGone {
+			l := stockLine{int(line.productID.Int64), int(line.variantID.Int64)}
+			restock[l] = returned{restock[l].quantity + item.Quantity, restock[l].cost + item.CostAmount}
+		}
+	}
+
+	// Same lock order as checkout
+	stockLines := make([]stockLine, 0, len(restock))
+	for l := range restock {
+		stockLines = append(stockLines, l)
+	}
+	sort.Slice(stockLines, func(i, j int) bool {
+		if stockLines[i].product != stockLines[j].product {
+			return stockLines[i].product < stockLines[j].product
+		}
+		return stockLines[i].variant < stockLines[j].variant
+	})
+	for _, l := range stockLines {
+		back := restock[l]
+		err := moveStock(ctx, tx, &models.StockMovement{
+			ProductID:   l.product,
+			VariantID:   l.variant,
+			Quantity:    back.quantity,
+			Reason:      models.StockMovementRefund,
+			ReferenceID: refund.ID,
+			UnitCost:    (back.cost + back.quantity/2) / back.quantity,
+		})
 		if err != nil {
 			return err
 		}
@@ -64,32 +709,198 @@ func (r *postgresTransactionRepository) CreateTransaction(t *models.Transaction)
 	return tx.Commit()
 }
 
-func (r *postgresTransactionRepository) GetSalesSummary(startDate, endDate time.Time) (models.SalesReport, error) {
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+// lockStock takes a row lock on every product in the checkout and verifies
+// that the requested quantities are available. Rows are locked in id order so
+// two checkouts touching the same products queue up instead of deadlocking.
+// Variant stock is checked on the variant, its product row is locked all the
+// same since every change to a variant's stock also goes through it.
+func lockStock(ctx context.Context, tx *sql.Tx, details []models.TransactionDetail) error {
+	requested := make(map[int]int)
+	requestedVariants := make(map[int]int)
+	ids := make([]int, 0, len(details))
+	var variantIDs []int
+	for _, detail := range details {
+		if _, ok := requested[detail.ProductID]; !ok {
+			ids = append(ids, detail.ProductID)
+			requested[detail.ProductID] = 0
+		}
+		if detail.VariantID == 0 {
+			requested[detail.ProductID] += detail.Quantity
+			continue
+		}
+		if _, ok := requestedVariants[detail.VariantID]; !ok {
+			variantIDs = append(variantIDs, detail.VariantID)
+		}
+		requestedVariants[detail.VariantID] += detail.Quantity
+	}
+	sort.Ints(ids)
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, name, stock FROM products WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+	var shortages []models.StockShortage
+	for rows.Next() {
+		var id, stock int
+		var name string
+		if err := rows.Scan(&id, &name, &stock); err != nil {
+			return err
+		}
+		found[id] = true
+		if stock < requested[id] {
+			shortages = append(shortages, models.StockShortage{
+				ProductID:   id,
+				ProductName: name,
+				Requested:   requested[id],
+				Available:   stock,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("%w: id %d", ErrProductNotFound, id)
+		}
+	}
+
+	if len(variantIDs) > 0 {
+		variantShortages, err := checkVariantStock(ctx, tx, variantIDs, requestedVariants)
+		if err != nil {
+			return err
+		}
+		shortages = append(shortages, variantShortages...)
+	}
+
+	if len(shortages) > 0 {
+		return &InsufficientStockError{Items: shortages}
+	}
+	return nil
+}
+
+func checkVariantStock(ctx context.Context, tx *sql.Tx, ids []int, requested map[int]int) ([]models.StockShortage, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT v.id, v.product_id, p.name, v.option_values, v.stock
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.id = ANY($1)
+		ORDER BY v.id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+	var shortages []models.StockShortage
+	for rows.Next() {
+		var id, productID, stock int
+		var name string
+		var options []string
+		if err := rows.Scan(&id, &productID, &name, pq.Array(&options), &stock); err != nil {
+			return nil, err
+		}
+		found[id] = true
+		if stock < requested[id] {
+			shortages = append(shortages, models.StockShortage{
+				ProductID:   productID,
+				ProductName: name,
+				VariantID:   id,
+				VariantName: models.VariantName(options),
+				Requested:   requested[id],
+				Available:   stock,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: variant id %d", ErrProductNotFound, id)
+		}
+	}
+	return shortages, nil
+}
+
+// GetSalesSummary reports the sales from startDate up to, not including,
+// endDate. The heatmap reads hours in the time zone of startDate, which must
+// be a named zone Postgres knows.
+func (r *postgresTransactionRepository) GetSalesSummary(ctx context.Context, startDate, endDate time.Time, opts models.ReportOptions) (models.SalesReport, error) {
 	var report models.SalesReport
 
-	// 1. Get Total Revenue & Total Transaction
+	// 1. Get Gross Sales, Discounts, Tax, Service Charge, Total Revenue & Total Transaction
 	querySummary := `
-		SELECT COALESCE(SUM(total_amount), 0), COUNT(id)
+		SELECT COALESCE(SUM(gross_amount), 0), COALESCE(SUM(discount_amount), 0),
+		       COALESCE(SUM(tax_amount), 0), COALESCE(SUM(service_charge), 0),
+		       COALESCE(SUM(total_amount), 0), COUNT(id)
 		FROM transactions
-		WHERE created_at BETWEEN $1 AND $2
+		WHERE created_at >= $1 AND created_at < $2
 	`
-	err := r.db.QueryRow(querySummary, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+	err := r.db.QueryRowContext(ctx, querySummary, startDate, endDate).Scan(&report.GrossSales, &report.TotalDiscount,
+		&report.TotalTax, &report.TotalServiceCharge, &report.TotalRevenue, &report.TotalTransaksi)
+	if err != nil {
+		return report, err
+	}
+
+	// 2. Net out voids and refunds made in the period, with the tax and
+	// service charge they gave back
+	var refundedTax, refundedCharge int
+	err = refundTotals(ctx, r.db, startDate, endDate).Scan(new(int), &report.TotalRefund, &refundedTax, &refundedCharge)
 	if err != nil {
 		return report, err
 	}
+	report.TotalRevenue -= report.TotalRefund
+	report.TotalTax -= refundedTax
+	report.TotalServiceCharge -= refundedCharge
 
-	// 2. Get Best Selling Product
+	report.TaxBreakdown, err = r.getTaxBreakdown(ctx, startDate, endDate)
+	if err != nil {
+		return report, err
+	}
+
+	// 3. Get Best Selling Product: units sold in the period less units
+	// returned in it, like the revenue above. Variants roll up into their
+	// product, which is named as it was last sold so a rename does not split
+	// it; a deleted product is told apart by its name
 	queryBestSeller := `
-		SELECT p.name, COALESCE(SUM(td.quantity), 0) as qty_terjual
-		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE t.created_at BETWEEN $1 AND $2
-		GROUP BY p.name
-		ORDER BY qty_terjual DESC
+		SELECT (ARRAY_AGG(name ORDER BY sold_at DESC))[1], SUM(quantity) AS qty_terjual
+		FROM (
+			SELECT td.product_id, td.product_name AS name, td.quantity, t.created_at AS sold_at
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			UNION ALL
+			SELECT td.product_id, td.product_name, -ri.quantity, t.created_at
+			FROM refund_items ri
+			JOIN refunds rf ON ri.refund_id = rf.id
+			JOIN transaction_details td ON ri.transaction_detail_id = td.id
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE rf.created_at >= $1 AND rf.created_at < $2
+		) lines
+		GROUP BY product_id, CASE WHEN product_id IS NULL THEN name END
+		HAVING SUM(quantity) > 0
+		ORDER BY qty_terjual DESC, 1
 		LIMIT 1
 	`
-	err = r.db.QueryRow(queryBestSeller, startDate, endDate).Scan(&report.ProdukTerlaris.Name, &report.ProdukTerlaris.QtyTerjual)
+	err = r.db.QueryRowContext(ctx, queryBestSeller, startDate, endDate).Scan(&report.ProdukTerlaris.Name, &report.ProdukTerlaris.QtyTerjual)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// No sales yet
@@ -99,5 +910,279 @@ func (r *postgresTransactionRepository) GetSalesSummary(startDate, endDate time.
 		}
 	}
 
+	// 4. Gross profit per product and per category, the overall figures are
+	// the sum of the products
+	report.ProfitByProduct, err = r.getProfit(ctx, startDate, endDate, "COALESCE(td.product_id, 0)", "td.product_name")
+	if err != nil {
+		return report, err
+	}
+	report.ProfitByCategory, err = r.getProfit(ctx, startDate, endDate, "COALESCE(td.category_id, 0)", "COALESCE(td.category_name, '')")
+	if err != nil {
+		return report, err
+	}
+	for _, p := range report.ProfitByProduct {
+		report.NetSales += p.NetSales
+		report.CostOfGoodsSold += p.CostOfGoodsSold
+	}
+	report.GrossProfit = report.NetSales - report.CostOfGoodsSold
+	report.GrossMargin = grossMargin(report.GrossProfit, report.NetSales)
+
+	// 5. The sections asked for
+	if opts.Has(models.ReportTopProducts) {
+		report.TopProducts, err = r.getSalesBreakdown(ctx, startDate, endDate, "COALESCE(td.product_id, 0)", "td.product_name",
+			"SUM(quantity) DESC, SUM(revenue) DESC", opts.Top)
+		if err != nil {
+			return report, err
+		}
+	}
+	if opts.Has(models.ReportCategories) {
+		report.Categories, err = r.getSalesBreakdown(ctx, startDate, endDate, "COALESCE(td.category_id, 0)", "COALESCE(td.category_name, '')",
+			"SUM(revenue) DESC", 0)
+		if err != nil {
+			return report, err
+		}
+	}
+	if opts.Has(models.ReportHeatmap) {
+		if report.Heatmap, err = r.getHeatmap(ctx, startDate, endDate); err != nil {
+			return report, err
+		}
+	}
+	if opts.Has(models.ReportPayments) {
+		if report.Payments, err = paymentSummary(ctx, r.db, startDate, endDate); err != nil {
+			return report, err
+		}
+	}
+	if opts.Has(models.ReportBasket) {
+		if report.Basket, err = r.getBasket(ctx, startDate, endDate); err != nil {
+			return report, err
+		}
+	}
+
 	return report, nil
 }
+
+// getSalesBreakdown totals quantity and revenue per id and name, sales made in
+// the period less refunds made in it, sorted by order. A limit of 0 returns
+// every row.
+func (r *postgresTransactionRepository) getSalesBreakdown(ctx context.Context, startDate, endDate time.Time, id, name, order string, limit int) ([]models.SalesBreakdown, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, SUM(quantity), SUM(revenue)
+		FROM (
+			SELECT %[1]s AS id, %[2]s AS name, td.quantity,
+			       td.subtotal + td.service_charge + CASE WHEN td.tax_inclusive THEN 0 ELSE td.tax_amount END AS revenue
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			UNION ALL
+			SELECT %[1]s, %[2]s, -ri.quantity, -ri.amount
+			FROM refund_items ri
+			JOIN refunds rf ON ri.refund_id = rf.id
+			JOIN transaction_details td ON ri.transaction_detail_id = td.id
+			WHERE rf.created_at >= $1 AND rf.created_at < $2
+		) lines
+		GROUP BY id, name
+		HAVING SUM(quantity) <> 0 OR SUM(revenue) <> 0
+		ORDER BY %[3]s, name`, id, name, order)
+	args := []interface{}{startDate, endDate}
+	if limit > 0 {
+		query += " LIMIT $3"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdown := []models.SalesBreakdown{}
+	for rows.Next() {
+		var s models.SalesBreakdown
+		if err := rows.Scan(&s.ID, &s.Name, &s.Quantity, &s.Revenue); err != nil {
+			return nil, err
+		}
+		breakdown = append(breakdown, s)
+	}
+	return breakdown, rows.Err()
+}
+
+// getHeatmap counts the sales of the period per day of the week and hour on
+// the clock of startDate's time zone, only the cells with sales are returned.
+func (r *postgresTransactionRepository) getHeatmap(ctx context.Context, startDate, endDate time.Time) ([]models.HeatmapCell, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT EXTRACT(DOW FROM created_at AT TIME ZONE $3)::int, EXTRACT(HOUR FROM created_at AT TIME ZONE $3)::int,
+		       COUNT(*), SUM(total_amount)
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, startDate, endDate, startDate.Location().String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cells := []models.HeatmapCell{}
+	for rows.Next() {
+		var c models.HeatmapCell
+		if err := rows.Scan(&c.DayOfWeek, &c.Hour, &c.Transactions, &c.Revenue); err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+	return cells, rows.Err()
+}
+
+// refundTotals counts the refunds made in the period and totals what they paid
+// back, and the tax and service charge given back with it, in that order.
+func refundTotals(ctx context.Context, q queryer, startDate, endDate time.Time) *sql.Row {
+	return q.QueryRowContext(ctx, `
+		WITH period AS (
+			SELECT id, amount
+			FROM refunds
+			WHERE created_at >= $1 AND created_at < $2
+		), items AS (
+			SELECT COALESCE(SUM(ri.tax_amount), 0) AS tax_amount, COALESCE(SUM(ri.service_charge), 0) AS service_charge
+			FROM refund_items ri
+			JOIN period ON ri.refund_id = period.id
+		)
+		SELECT (SELECT COUNT(*) FROM period), (SELECT COALESCE(SUM(amount), 0) FROM period), tax_amount, service_charge
+		FROM items
+	`, startDate, endDate)
+}
+
+// paymentSummary totals the payments of the sales made in the period per
+// method. The change of a sale is taken off what it was paid in cash.
+func paymentSummary(ctx context.Context, q queryer, startDate, endDate time.Time) ([]models.PaymentSummary, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT method, COUNT(*), SUM(amount)
+		FROM (
+			SELECT p.method, SUM(p.amount) - CASE WHEN p.method = $3 THEN MAX(t.change_amount) ELSE 0 END AS amount
+			FROM payments p
+			JOIN transactions t ON p.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			GROUP BY p.transaction_id, p.method
+		) per_sale
+		GROUP BY method
+		ORDER BY SUM(amount) DESC, method
+	`, startDate, endDate, models.PaymentMethodCash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []models.PaymentSummary{}
+	for rows.Next() {
+		var p models.PaymentSummary
+		if err := rows.Scan(&p.Method, &p.Transactions, &p.Amount); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, p)
+	}
+	return summaries, rows.Err()
+}
+
+// getBasket averages the sales made in the period. Voided sales are left out,
+// a void cancels the sale; partial refunds are not taken off what was bought.
+func (r *postgresTransactionRepository) getBasket(ctx context.Context, startDate, endDate time.Time) (*models.BasketSummary, error) {
+	var basket models.BasketSummary
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(ROUND(AVG(t.total_amount)), 0)::int,
+		       COALESCE(ROUND(AVG(items.quantity), 2), 0)::float8
+		FROM transactions t
+		JOIN (
+			SELECT transaction_id, SUM(quantity) AS quantity
+			FROM transaction_details
+			GROUP BY transaction_id
+		) items ON items.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2
+		  AND NOT EXISTS (SELECT 1 FROM refunds r WHERE r.transaction_id = t.id AND r.type = $3)
+	`, startDate, endDate, models.RefundTypeVoid).Scan(&basket.AverageBasket, &basket.ItemsPerTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return &basket, nil
+}
+
+// getProfit totals net sales and their cost per id and name, sales made in the
+// period less refunds made in it, most profitable first. Net sales are the
+// tax base of the lines, what was sold before tax and after discounts.
+func (r *postgresTransactionRepository) getProfit(ctx context.Context, startDate, endDate time.Time, id, name string) ([]models.ProfitSummary, error) {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, name, SUM(quantity), SUM(net_sales), SUM(cost)
+		FROM (
+			SELECT %[1]s AS id, %[2]s AS name, td.quantity, td.tax_base AS net_sales, td.cost_amount AS cost
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			UNION ALL
+			SELECT %[1]s, %[2]s, -ri.quantity, -ri.tax_base, -ri.cost_amount
+			FROM refund_items ri
+			JOIN refunds rf ON ri.refund_id = rf.id
+			JOIN transaction_details td ON ri.transaction_detail_id = td.id
+			WHERE rf.created_at >= $1 AND rf.created_at < $2
+		) lines
+		GROUP BY id, name
+		ORDER BY SUM(net_sales) - SUM(cost) DESC, name
+	`, id, name), startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []models.ProfitSummary{}
+	for rows.Next() {
+		var p models.ProfitSummary
+		if err := rows.Scan(&p.ID, &p.Name, &p.Quantity, &p.NetSales, &p.CostOfGoodsSold); err != nil {
+			return nil, err
+		}
+		p.GrossProfit = p.NetSales - p.CostOfGoodsSold
+		p.GrossMargin = grossMargin(p.GrossProfit, p.NetSales)
+		summaries = append(summaries, p)
+	}
+	return summaries, rows.Err()
+}
+
+// grossMargin is profit in percent of sales, to two decimals.
+func grossMargin(profit, sales int) float64 {
+	if sales == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)*10000/float64(sales)) / 100
+}
+
+// getTaxBreakdown groups the tax of the sales made in the period by rule, less
+// the tax given back by refunds made in the period.
+func (r *postgresTransactionRepository) getTaxBreakdown(ctx context.Context, startDate, endDate time.Time) ([]models.TaxSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tax_rate, tax_inclusive, SUM(tax_base), SUM(tax_amount)
+		FROM (
+			SELECT td.tax_rate, td.tax_inclusive, td.tax_base, td.tax_amount
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			UNION ALL
+			SELECT td.tax_rate, td.tax_inclusive, -ri.tax_base, -ri.tax_amount
+			FROM refund_items ri
+			JOIN refunds rf ON ri.refund_id = rf.id
+			JOIN transaction_details td ON ri.transaction_detail_id = td.id
+			WHERE rf.created_at >= $1 AND rf.created_at < $2
+		) lines
+		GROUP BY tax_rate, tax_inclusive
+		ORDER BY tax_rate, tax_inclusive
+	`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdown := []models.TaxSummary{}
+	for rows.Next() {
+		var ts models.TaxSummary
+		if err := rows.Scan(&ts.Rate, &ts.Inclusive, &ts.TaxBase, &ts.TaxAmount); err != nil {
+			return nil, err
+		}
+		breakdown = append(breakdown, ts)
+	}
+	return breakdown, rows.Err()
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"kasir-api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// loadVariants fills in the options and variants of the given products with
+// one query each, regardless of how many products there are.
+func loadVariants(ctx context.Context, db *sql.DB, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*models.Product, len(products))
+	ids := make([]int, 0, len(products))
+	for i := range products {
+		index[products[i].ID] = &products[i]
+		ids = append(ids, products[i].ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT product_id, name, choices
+		FROM product_options
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var o models.ProductOption
+		if err := rows.Scan(&productID, &o.Name, pq.Array(&o.Values)); err != nil {
+			return err
+		}
+		p := index[productID]
+		p.Options = append(p.Options, o)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	variantRows, err := db.QueryContext(ctx, `
+		SELECT v.id, v.product_id, v.option_values, COALESCE(v.sku, ''), v.price, v.cost_price, v.stock,
+		       ARRAY(SELECT b.code FROM product_barcodes b WHERE b.variant_id = v.id ORDER BY b.code)
+		FROM product_variants v
+		WHERE v.product_id = ANY($1)
+		ORDER BY v.product_id, v.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer variantRows.Close()
+
+	for variantRows.Next() {
+		var v models.ProductVariant
+		var costPrice int
+		if err := variantRows.Scan(&v.ID, &v.ProductID, pq.Array(&v.Options), &v.SKU, &v.Price, &costPrice, &v.Stock,
+			pq.Array(&v.Barcodes)); err != nil {
+			return err
+		}
+		v.CostPrice = &costPrice
+		if v.Barcodes == nil {
+			v.Barcodes = []string{}
+		}
+		v.Name = models.VariantName(v.Options)
+		p := index[v.ProductID]
+		p.Variants = append(p.Variants, v)
+	}
+	return variantRows.Err()
+}
+
+// saveVariants replaces the options of a product and brings its variants in
+// line with the given ones: variants with an id are updated, the others are
+// added and those left out are removed. Barcodes of an existing variant are
+// only replaced when its Barcodes is not nil. Stock changes, including the
+// stock of a removed variant, are recorded as adjustments.
+func saveVariants(ctx context.Context, tx *sql.Tx, productID int, options []models.ProductOption, variants []models.ProductVariant) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM product_options WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+	for i, o := range options {
+		_, err := tx.ExecContext(ctx, "INSERT INTO product_options (product_id, position, name, choices) VALUES ($1, $2, $3, $4)",
+			productID, i, o.Name, pq.Array(o.Values))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := removeVariants(ctx, tx, productID, variants); err != nil {
+		return err
+	}
+
+	// Stock only comes in with a new variant, an existing one keeps its stock
+	// and changes it through stock movements
+	for _, v := range variants {
+		if v.ID == 0 {
+			err := tx.QueryRowContext(ctx,
+				"INSERT INTO product_variants (product_id, option_values, sku, price, cost_price, stock) VALUES ($1, $2, $3, $4, COALESCE($5, 0), 0) RETURNING id",
+				productID, pq.Array(v.Options), nullString(v.SKU), v.Price, v.CostPrice,
+			).Scan(&v.ID)
+			if err != nil {
+				return productWriteError(err)
+			}
+			if err := setBarcodes(ctx, tx, productID, v.ID, v.Barcodes); err != nil {
+				return err
+			}
+			if v.Stock != 0 {
+				err := moveStock(ctx, tx, &models.StockMovement{
+					ProductID: productID,
+					VariantID: v.ID,
+					Quantity:  v.Stock,
+					Reason:    models.StockMovementAdjustment,
+					Note:      openingStockNote,
+				})
+				if err != nil {
+					return productWriteError(err)
+				}
+			}
+		} else {
+			result, err := tx.ExecContext(ctx,
+				"UPDATE product_variants SET option_values = $1, sku = $2, price = $3, cost_price = COALESCE($4, cost_price) WHERE id = $5 AND product_id = $6",
+				pq.Array(v.Options), nullString(v.SKU), v.Price, v.CostPrice, v.ID, productID,
+			)
+			if err != nil {
+				return productWriteError(err)
+			}
+			if n, err := result.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				return fmt.Errorf("%w: variant id %d", ErrUnknownVariant, v.ID)
+			}
+
+			if v.Barcodes != nil {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM product_barcodes WHERE variant_id = $1", v.ID); err != nil {
+					return err
+				}
+				if err := setBarcodes(ctx, tx, productID, v.ID, v.Barcodes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// removeVariants deletes the variants of a product that are not in keep,
+// taking their stock out through the ledger first.
+func removeVariants(ctx context.Context, tx *sql.Tx, productID int, keep []models.ProductVariant) error {
+	ids := []int{}
+	for _, v := range keep {
+		if v.ID != 0 {
+			ids = append(ids, v.ID)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, stock FROM product_variants WHERE product_id = $1 AND NOT (id = ANY($2)) AND stock <> 0 ORDER BY id",
+		productID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	var movements []models.StockMovement
+	for rows.Next() {
+		m := models.StockMovement{ProductID: productID, Reason: models.StockMovementAdjustment, Note: "variant removed"}
+		if err := rows.Scan(&m.VariantID, &m.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		m.Quantity = -m.Quantity
+		movements = append(movements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range movements {
+		if err := moveStock(ctx, tx, &movements[i]); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM product_variants WHERE product_id = $1 AND NOT (id = ANY($2))", productID, pq.Array(ids))
+	return err
+}
+
+// setStock brings the stock of a product to the given level with an
+// adjustment noted with note, a nil stock keeps it where it is. A product with
+// variants is brought to the sum of theirs instead, which only differs from
+// its own stock when it just got its first variants.
+func setStock(ctx context.Context, tx *sql.Tx, productID int, stock *int, note string) error {
+	var current, variantStock int
+	var hasVariants bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT p.stock, COALESCE(SUM(v.stock), 0), COUNT(v.id) > 0
+		FROM products p
+		LEFT JOIN product_variants v ON v.product_id = p.id
+		WHERE p.id = $1
+		GROUP BY p.id
+	`, productID).Scan(&current, &variantStock, &hasVariants)
+	if err != nil {
+		return err
+	}
+
+	level := current
+	switch {
+	case hasVariants:
+		level = variantStock
+	case stock != nil:
+		level = *stock
+	}
+	if level == current {
+		return nil
+	}
+	err = moveStock(ctx, tx, &models.StockMovement{
+		ProductID: productID,
+		Quantity:  level - current,
+		Reason:    models.StockMovementAdjustment,
+		Note:      note,
+	})
+	return productWriteError(err)
+}
@@ -0,0 +1,168 @@
+// Package server maps the API routes onto their handlers.
+package server
+
+import (
+	"encoding/json"
+	"kasir-api/internal/config"
+	"kasir-api/internal/handlers"
+	"net/http"
+	"strings"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+type Handlers struct {
+	Product     *handlers.ProductHandler
+	Category    *handlers.CategoryHandler
+	Promotion   *handlers.PromotionHandler
+	Transaction *handlers.TransactionHandler
+	Stock       *handlers.StockMovementHandler
+	Opname      *handlers.StockOpnameHandler
+	Supplier    *handlers.SupplierHandler
+	Purchase    *handlers.PurchaseOrderHandler
+	Closing     *handlers.DayClosingHandler
+}
+
+// methods are probed to fill the Allow header when a path exists but not for
+// the method that was asked for.
+var methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type router struct {
+	mux *http.ServeMux
+}
+
+// New returns the API handler. Unknown paths answer 404 and known paths hit
+// with the wrong method answer 405 with an Allow header, both in the JSON
+// error envelope.
+func New(cfg config.Config, h Handlers) http.Handler {
+	mux := http.NewServeMux()
+	query := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, handlers.WithTimeout(cfg.QueryTimeout, handler))
+	}
+	write := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, handlers.WithTimeout(cfg.CheckoutTimeout, handler))
+	}
+	report := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, handlers.WithTimeout(cfg.ReportTimeout, handler))
+	}
+	// exportable listings get the report timeout when asked for as a file
+	exportable := func(pattern string, handler http.HandlerFunc) {
+		asQuery := handlers.WithTimeout(cfg.QueryTimeout, handler)
+		asReport := handlers.WithTimeout(cfg.ReportTimeout, handler)
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if f := r.URL.Query().Get("format"); f != "" && f != "json" {
+				asReport(w, r)
+				return
+			}
+			asQuery(w, r)
+		})
+	}
+
+	mux.HandleFunc("GET /{$}", welcome)
+
+	// Product
+	query("GET /api/product", h.Product.GetProduct)
+	query("POST /api/product", h.Product.CreateProduct)
+	query("GET /api/product/{id}", h.Product.GetDetailProduct)
+	query("GET /api/product/barcode/{code}", h.Product.GetProductByBarcode)
+	query("PUT /api/product/{id}", h.Product.UpdateProduct)
+	query("DELETE /api/product/{id}", h.Product.DeleteProduct)
+
+	// Stock
+	query("GET /api/stock-movements", h.Stock.GetStockMovements)
+	write("POST /api/stock-movements", h.Stock.CreateStockMovement)
+	query("GET /api/stock-opnames", h.Opname.GetStockOpnames)
+	write("POST /api/stock-opnames", h.Opname.OpenStockOpname)
+	report("GET /api/stock-opnames/{id}", h.Opname.GetStockOpname)
+	write("PUT /api/stock-opnames/{id}/counts", h.Opname.CountStockOpname)
+	write("POST /api/stock-opnames/{id}/post", h.Opname.PostStockOpname)
+	write("POST /api/stock-opnames/{id}/cancel", h.Opname.CancelStockOpname)
+
+	// Category
+	query("GET /api/category", h.Category.GetAll)
+	query("POST /api/category", h.Category.Create)
+	query("GET /api/category/{id}", h.Category.GetDetailCategories)
+	query("PUT /api/category/{id}", h.Category.Update)
+	query("DELETE /api/category/{id}", h.Category.Delete)
+
+	// Promotion
+	query("GET /api/promotion", h.Promotion.GetAll)
+	query("POST /api/promotion", h.Promotion.Create)
+	query("GET /api/promotion/{id}", h.Promotion.GetDetail)
+	query("PUT /api/promotion/{id}", h.Promotion.Update)
+	query("DELETE /api/promotion/{id}", h.Promotion.Delete)
+
+	// Purchasing
+	query("GET /api/suppliers", h.Supplier.GetAll)
+	query("POST /api/suppliers", h.Supplier.Create)
+	query("GET /api/suppliers/{id}", h.Supplier.GetDetail)
+	query("PUT /api/suppliers/{id}", h.Supplier.Update)
+	query("DELETE /api/suppliers/{id}", h.Supplier.Delete)
+	query("GET /api/purchase-orders", h.Purchase.GetPurchaseOrders)
+	write("POST /api/purchase-orders", h.Purchase.CreatePurchaseOrder)
+	query("GET /api/purchase-orders/{id}", h.Purchase.GetPurchaseOrder)
+	write("PUT /api/purchase-orders/{id}", h.Purchase.UpdatePurchaseOrder)
+	write("POST /api/purchase-orders/{id}/cancel", h.Purchase.CancelPurchaseOrder)
+	write("POST /api/purchase-orders/{id}/receipts", h.Purchase.ReceivePurchaseOrder)
+
+	// Transaction
+	write("POST /api/checkout", h.Transaction.HandleCheckout)
+	exportable("GET /api/transactions", h.Transaction.HandleTransactionList)
+	query("GET /api/transactions/{id}", h.Transaction.GetTransaction)
+	write("POST /api/transactions/{id}/void", h.Transaction.VoidTransaction)
+	write("POST /api/transactions/{id}/refund", h.Transaction.RefundTransaction)
+
+	// Report
+	report("GET /api/report/hari-ini", h.Transaction.HandleDailyReport)
+	report("GET /api/report", h.Transaction.HandleReport)
+	report("GET /api/report/outstanding-po", h.Purchase.HandleOutstandingReport)
+
+	// Day closing
+	query("GET /api/closing", h.Closing.GetDayClosings)
+	write("POST /api/closing", h.Closing.CloseDay)
+	report("GET /api/closing/x", h.Closing.GetXReport)
+	query("GET /api/closing/{date}", h.Closing.GetDayClosing)
+
+	// Swagger
+	mux.HandleFunc("GET /swagger/", httpSwagger.WrapHandler)
+
+	return handlers.RequestID(handlers.User(&router{mux: mux}))
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		if allowed := rt.allowed(r); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			handlers.MethodNotAllowed(w, r)
+			return
+		}
+		handlers.NotFound(w, r)
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// allowed lists the methods that have a route for r's path.
+func (rt *router) allowed(r *http.Request) []string {
+	var allowed []string
+	for _, method := range methods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+			if method == http.MethodGet {
+				allowed = append(allowed, http.MethodHead)
+			}
+		}
+	}
+	return allowed
+}
+
+func welcome(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]string{
+		"message": "Welcome to Kasir API",
+		"status":  "running",
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"kasir-api/internal/config"
+	"kasir-api/internal/handlers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serve runs a request through the routes; the handlers are never reached.
+func serve(t *testing.T, method, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	New(config.Config{}, Handlers{}).ServeHTTP(w, r)
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) handlers.APIError {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	var body handlers.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	return body.Error
+}
+
+func TestUnknownPath(t *testing.T) {
+	for _, path := range []string{"/api/nope", "/api/product/1/nope", "/favicon.ico"} {
+		t.Run(path, func(t *testing.T) {
+			w := serve(t, http.MethodGet, path, nil)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			apiErr := decodeError(t, w)
+			if apiErr.Code != handlers.CodeNotFound {
+				t.Errorf("code = %q, want %q", apiErr.Code, handlers.CodeNotFound)
+			}
+			if apiErr.RequestID == "" || apiErr.RequestID != w.Header().Get("X-Request-ID") {
+				t.Errorf("request ID in body %q, in header %q", apiErr.RequestID, w.Header().Get("X-Request-ID"))
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		method, path, allow string
+	}{
+		{http.MethodPatch, "/api/product", "GET, HEAD, POST"},
+		{http.MethodPost, "/api/product/1", "GET, HEAD, PUT, DELETE"},
+		{http.MethodGet, "/api/checkout", "POST"},
+		{http.MethodDelete, "/api/closing/x", "GET, HEAD"},
+		{http.MethodGet, "/api/stock-opnames/1/post", "POST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := serve(t, tt.method, tt.path, nil)
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q, want %q", allow, tt.allow)
+			}
+			if apiErr := decodeError(t, w); apiErr.Code != handlers.CodeMethodNotAllowed {
+				t.Errorf("code = %q, want %q", apiErr.Code, handlers.CodeMethodNotAllowed)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	w := serve(t, http.MethodGet, "/", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if id := w.Header().Get("X-Request-ID"); id == "" {
+		t.Error("no X-Request-ID on the response")
+	}
+
+	w = serve(t, http.MethodGet, "/", http.Header{"X-Request-Id": {"from-proxy"}})
+	if id := w.Header().Get("X-Request-ID"); id != "from-proxy" {
+		t.Errorf("X-Request-ID = %q, want the one the proxy set", id)
+	}
+}
+
+func TestRequestIDIsUnique(t *testing.T) {
+	first := serve(t, http.MethodGet, "/", nil).Header().Get("X-Request-ID")
+	second := serve(t, http.MethodGet, "/", nil).Header().Get("X-Request-ID")
+	if first == second {
+		t.Errorf("two requests share the ID %q", first)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// BusinessDay maps moments onto the store's business days. A business day
+// starts at the cutoff past midnight in the store's time zone and runs until
+// the cutoff of the next day, so with a 04:00 cutoff a sale at 01:30 counts
+// for the day before.
+type BusinessDay struct {
+	location *time.Location
+	cutoff   int // minutes past midnight
+}
+
+// NewBusinessDay reads the store time zone as an IANA name ("Asia/Jakarta")
+// and the cutoff as HH:MM. "Local" is refused: reports hand the zone's name to
+// the database, which does not know the machine's.
+func NewBusinessDay(timezone, cutoff string) (BusinessDay, error) {
+	if timezone == "Local" {
+		return BusinessDay{}, fmt.Errorf("invalid time zone %q, expected an IANA name such as Asia/Jakarta", timezone)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return BusinessDay{}, fmt.Errorf("invalid time zone %q: %w", timezone, err)
+	}
+	clock, err := time.Parse("15:04", cutoff)
+	if err != nil {
+		return BusinessDay{}, fmt.Errorf("invalid business day cutoff %q, expected HH:MM", cutoff)
+	}
+	return BusinessDay{location: location, cutoff: clock.Hour()*60 + clock.Minute()}, nil
+}
+
+func (b BusinessDay) Location() *time.Location {
+	if b.location == nil {
+		return time.UTC
+	}
+	return b.location
+}
+
+// Now is the current time in the store's time zone.
+func (b BusinessDay) Now() time.Time {
+	return time.Now().In(b.Location())
+}
+
+// Date is the business day t falls in, as midnight of its date in the store's
+// time zone.
+func (b BusinessDay) Date(t time.Time) time.Time {
+	local := t.In(b.Location())
+	y, m, d := local.Date()
+	if local.Hour()*60+local.Minute() < b.cutoff {
+		d--
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, b.Location())
+}
+
+// Start is the moment the business day of date starts. Only the calendar date
+// of date counts, whatever its time and location.
+func (b BusinessDay) Start(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, b.cutoff/60, b.cutoff%60, 0, 0, b.Location())
+}
+
+// Range is the span of the business days from and to, both included: start is
+// the first moment in it and end the first moment after it.
+func (b BusinessDay) Range(from, to time.Time) (start, end time.Time) {
+	return b.Start(from), b.Start(to.AddDate(0, 0, 1))
+}
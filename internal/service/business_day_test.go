@@ -0,0 +1,154 @@
+package service
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the test does not rely on the machine's zoneinfo either
+)
+
+func mustBusinessDay(t *testing.T, timezone, cutoff string) BusinessDay {
+	t.Helper()
+	days, err := NewBusinessDay(timezone, cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return days
+}
+
+func TestBusinessDayDate(t *testing.T) {
+	days := mustBusinessDay(t, "Asia/Jakarta", "04:00")
+	jakarta := days.Location()
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"just before the cutoff", time.Date(2026, 10, 18, 3, 59, 59, 0, jakarta), "2026-10-17"},
+		{"at the cutoff", time.Date(2026, 10, 18, 4, 0, 0, 0, jakarta), "2026-10-18"},
+		{"late evening", time.Date(2026, 10, 18, 23, 59, 0, 0, jakarta), "2026-10-18"},
+		{"after midnight", time.Date(2026, 10, 19, 1, 30, 0, 0, jakarta), "2026-10-18"},
+		{"first night of a month", time.Date(2026, 11, 1, 2, 0, 0, 0, jakarta), "2026-10-31"},
+		{"new year's night", time.Date(2027, 1, 1, 0, 30, 0, 0, jakarta), "2026-12-31"},
+		{"leap day night", time.Date(2028, 3, 1, 3, 0, 0, 0, jakarta), "2028-02-29"},
+		// Stored in UTC: 20:59 and 21:00 UTC are 03:59 and 04:00 in Jakarta
+		{"UTC just before the cutoff", time.Date(2026, 10, 17, 20, 59, 0, 0, time.UTC), "2026-10-17"},
+		{"UTC at the cutoff", time.Date(2026, 10, 17, 21, 0, 0, 0, time.UTC), "2026-10-18"},
+		{"UTC afternoon", time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC), "2026-10-18"},
+		{"UTC evening is the next morning", time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC), "2026-10-19"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := days.Date(tt.at)
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("Date(%s) = %s, want %s", tt.at, got.Format("2006-01-02"), tt.want)
+			}
+			if got.Location() != jakarta || got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("Date(%s) = %s, want midnight in Jakarta", tt.at, got)
+			}
+		})
+	}
+}
+
+func TestBusinessDayMidnightCutoff(t *testing.T) {
+	days := mustBusinessDay(t, "Asia/Jakarta", "00:00")
+
+	// 17:00 UTC is midnight in Jakarta, a day ahead of the UTC date
+	for at, want := range map[time.Time]string{
+		time.Date(2026, 10, 17, 16, 59, 59, 0, time.UTC): "2026-10-17",
+		time.Date(2026, 10, 17, 17, 0, 0, 0, time.UTC):   "2026-10-18",
+	} {
+		if got := days.Date(at).Format("2006-01-02"); got != want {
+			t.Errorf("Date(%s) = %s, want %s", at, got, want)
+		}
+	}
+}
+
+func TestBusinessDayRange(t *testing.T) {
+	days := mustBusinessDay(t, "Asia/Jakarta", "04:00")
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name       string
+		from, to   string
+		start, end string
+	}{
+		{"one day", "2026-10-18", "2026-10-18", "2026-10-17T21:00:00Z", "2026-10-18T21:00:00Z"},
+		{"across months", "2026-10-30", "2026-11-02", "2026-10-29T21:00:00Z", "2026-11-02T21:00:00Z"},
+		{"last day of a month", "2026-10-31", "2026-10-31", "2026-10-30T21:00:00Z", "2026-10-31T21:00:00Z"},
+		{"across years", "2026-12-31", "2027-01-01", "2026-12-30T21:00:00Z", "2027-01-01T21:00:00Z"},
+		{"through a leap day", "2028-02-28", "2028-02-29", "2028-02-27T21:00:00Z", "2028-02-29T21:00:00Z"},
+		{"whole month", "2026-02-01", "2026-02-28", "2026-01-31T21:00:00Z", "2026-02-28T21:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := days.Range(date(tt.from), date(tt.to))
+			if got := start.UTC().Format(time.RFC3339); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := end.UTC().Format(time.RFC3339); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestBusinessDayRangeHoldsItsSales(t *testing.T) {
+	days := mustBusinessDay(t, "Asia/Jakarta", "04:00")
+	jakarta := days.Location()
+	start, end := days.Range(time.Date(2026, 10, 31, 0, 0, 0, 0, jakarta), time.Date(2026, 11, 1, 0, 0, 0, 0, jakarta))
+
+	for at, in := range map[time.Time]bool{
+		time.Date(2026, 10, 31, 3, 59, 0, 0, jakarta): false,
+		time.Date(2026, 10, 31, 4, 0, 0, 0, jakarta):  true,
+		time.Date(2026, 11, 2, 3, 59, 0, 0, jakarta):  true,
+		time.Date(2026, 11, 2, 4, 0, 0, 0, jakarta):   false,
+	} {
+		if got := !at.Before(start) && at.Before(end); got != in {
+			t.Errorf("%s in [%s, %s) = %v, want %v", at, start, end, got, in)
+		}
+	}
+}
+
+// Start takes the calendar date of its argument as it is written, not the
+// date it would be in the store's time zone.
+func TestBusinessDayStartIgnoresClock(t *testing.T) {
+	days := mustBusinessDay(t, "Asia/Jakarta", "04:00")
+	want := time.Date(2026, 10, 18, 4, 0, 0, 0, days.Location())
+	for _, date := range []time.Time{
+		time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 18, 1, 0, 0, 0, days.Location()),
+	} {
+		if got := days.Start(date); !got.Equal(want) {
+			t.Errorf("Start(%s) = %s, want %s", date, got, want)
+		}
+	}
+}
+
+func TestNewBusinessDay(t *testing.T) {
+	tests := []struct {
+		timezone, cutoff string
+		ok               bool
+	}{
+		{"Asia/Jakarta", "04:00", true},
+		{"Asia/Makassar", "00:00", true},
+		{"UTC", "23:59", true},
+		{"Asia/Atlantis", "04:00", false},
+		{"Local", "04:00", false},
+		{"Asia/Jakarta", "4am", false},
+		{"Asia/Jakarta", "24:00", false},
+		{"Asia/Jakarta", "", false},
+	}
+	for _, tt := range tests {
+		if _, err := NewBusinessDay(tt.timezone, tt.cutoff); (err == nil) != tt.ok {
+			t.Errorf("NewBusinessDay(%q, %q) error %v, want ok %v", tt.timezone, tt.cutoff, err, tt.ok)
+		}
+	}
+}
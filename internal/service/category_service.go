@@ -1,16 +1,19 @@
 package service
 
 import (
+	"context"
 	"kasir-api/internal/models"
 	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
 )
 
 type CategoryService interface {
-	GetAll() []models.Category
-	GetByID(id int) (*models.Category, error)
-	Create(c models.Category) models.Category
-	Update(id int, c models.Category) (*models.Category, error)
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]models.Category, error)
+	GetByID(ctx context.Context, id int) (*models.Category, error)
+	Create(ctx context.Context, c models.Category) (*models.Category, error)
+	Update(ctx context.Context, id int, c models.Category) (*models.Category, error)
+	Delete(ctx context.Context, id int) error
 }
 
 type categoryService struct {
@@ -21,22 +24,30 @@ func NewCategoryService(repo repository.CategoriesRepository) CategoryService {
 	return &categoryService{repo: repo}
 }
 
-func (s *categoryService) GetAll() []models.Category {
-	return s.repo.GetAll()
+func (s *categoryService) GetAll(ctx context.Context) ([]models.Category, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *categoryService) GetByID(id int) (*models.Category, error) {
-	return s.repo.GetByID(id)
+func (s *categoryService) GetByID(ctx context.Context, id int) (*models.Category, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *categoryService) Create(c models.Category) models.Category {
-	return s.repo.Create(c)
+func (s *categoryService) Create(ctx context.Context, c models.Category) (*models.Category, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if err := validate.Struct(c).Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, c)
 }
 
-func (s *categoryService) Update(id int, c models.Category) (*models.Category, error) {
-	return s.repo.Update(id, c)
+func (s *categoryService) Update(ctx context.Context, id int, c models.Category) (*models.Category, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if err := validate.Struct(c).Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, c)
 }
 
-func (s *categoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *categoryService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
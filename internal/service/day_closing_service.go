@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClosingPerPage = 20
+	maxClosingPerPage     = 100
+)
+
+type DayClosingService interface {
+	GetAll(ctx context.Context, filter models.DayClosingFilter) (models.DayClosingList, error)
+	GetByDate(ctx context.Context, date time.Time) (*models.DayClosing, error)
+	XReport(ctx context.Context, date time.Time, openingCash int, countedCash *int) (*models.DayClosing, error)
+	Close(ctx context.Context, req models.DayClosingRequest) (*models.DayClosing, error)
+}
+
+type dayClosingService struct {
+	repo repository.DayClosingRepository
+	days BusinessDay
+}
+
+// NewDayClosingService returns the service. days decides what a business date
+// covers and which one is current.
+func NewDayClosingService(repo repository.DayClosingRepository, days BusinessDay) DayClosingService {
+	return &dayClosingService{repo: repo, days: days}
+}
+
+func (s *dayClosingService) GetAll(ctx context.Context, filter models.DayClosingFilter) (models.DayClosingList, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultClosingPerPage
+	}
+	if filter.PerPage > maxClosingPerPage {
+		filter.PerPage = maxClosingPerPage
+	}
+
+	closings, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return models.DayClosingList{}, err
+	}
+	for i := range closings {
+		s.local(&closings[i])
+	}
+
+	return models.DayClosingList{
+		Data:    closings,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+// GetByDate returns the stored Z report of the business day; only the date of
+// date counts.
+func (s *dayClosingService) GetByDate(ctx context.Context, date time.Time) (*models.DayClosing, error) {
+	c, err := s.repo.GetByDate(ctx, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	s.local(c)
+	return c, nil
+}
+
+// XReport totals a business day as it stands, the current one when date is
+// zero. Nothing is stored and the day stays open.
+func (s *dayClosingService) XReport(ctx context.Context, date time.Time, openingCash int, countedCash *int) (*models.DayClosing, error) {
+	var errs validate.Errors
+	if date.IsZero() {
+		date = s.days.Date(time.Now())
+	} else if s.notStarted(date) {
+		errs.Add("date", "must not be a business day that has not started yet")
+	}
+	if openingCash < 0 {
+		errs.Add("opening_cash", "must be at least 0")
+	}
+	if countedCash != nil && *countedCash < 0 {
+		errs.Add("counted_cash", "must be at least 0")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	c := s.period(date)
+	c.Type = models.ClosingX
+	c.OpeningCash = openingCash
+	c.CountedCash = countedCash
+	if err := s.repo.Totals(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes a business day for good and returns its Z report.
+func (s *dayClosingService) Close(ctx context.Context, req models.DayClosingRequest) (*models.DayClosing, error) {
+	req.BusinessDate = strings.TrimSpace(req.BusinessDate)
+	req.Note = strings.TrimSpace(req.Note)
+	errs := validate.Struct(req)
+
+	date := s.days.Date(time.Now())
+	if req.BusinessDate != "" {
+		d, err := time.Parse("2006-01-02", req.BusinessDate)
+		switch {
+		case err != nil:
+			errs.Add("business_date", "must be a date as YYYY-MM-DD")
+		case s.notStarted(d):
+			errs.Add("business_date", "must not be a business day that has not started yet")
+		default:
+			date = d
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	c := s.period(date)
+	c.OpeningCash = req.OpeningCash
+	c.CountedCash = req.CountedCash
+	c.Note = req.Note
+	if err := s.repo.Close(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.GetByDate(ctx, date)
+}
+
+// notStarted reports whether the business day of date lies in the future.
+func (s *dayClosingService) notStarted(date time.Time) bool {
+	return s.days.Start(date).After(time.Now())
+}
+
+// period is an empty report covering the business day of date.
+func (s *dayClosingService) period(date time.Time) *models.DayClosing {
+	start, end := s.days.Range(date, date)
+	return &models.DayClosing{BusinessDate: date.Format("2006-01-02"), PeriodStart: start, PeriodEnd: end}
+}
+
+// local shows the times of a stored closing on the store's clock.
+func (s *dayClosingService) local(c *models.DayClosing) {
+	c.PeriodStart = c.PeriodStart.In(s.days.Location())
+	c.PeriodEnd = c.PeriodEnd.In(s.days.Location())
+	if c.ClosedAt != nil {
+		closedAt := c.ClosedAt.In(s.days.Location())
+		c.ClosedAt = &closedAt
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/internal/models"
+)
+
+var (
+	ErrInvalidPaymentMethod = errors.New("invalid payment method")
+	ErrInvalidPaymentAmount = errors.New("payment amount must be greater than zero")
+	ErrInsufficientPayment  = errors.New("payment does not cover the total")
+	ErrNonCashOverpayment   = errors.New("only cash can be overpaid, non-cash tenders exceed the total")
+)
+
+// settlePayments validates the tenders handed over for a sale of total and
+// returns the payments to record, the amount paid and the change (kembalian)
+// to give back. Change can only come out of cash: QRIS, debit and e-wallet
+// tenders must not exceed the total on their own.
+//
+// A checkout without tenders is treated as paid in exact cash, which keeps
+// older clients that never sent payments working.
+func settlePayments(total int, tenders []models.Payment) ([]models.Payment, int, int, error) {
+	if len(tenders) == 0 {
+		if total == 0 {
+			return []models.Payment{}, 0, 0, nil
+		}
+		return []models.Payment{{Method: models.PaymentMethodCash, Amount: total}}, total, 0, nil
+	}
+
+	payments := make([]models.Payment, 0, len(tenders))
+	var paid, cash int
+	for _, tender := range tenders {
+		if !tender.Method.Valid() {
+			return nil, 0, 0, fmt.Errorf("%w: %q", ErrInvalidPaymentMethod, tender.Method)
+		}
+		if tender.Amount <= 0 {
+			return nil, 0, 0, ErrInvalidPaymentAmount
+		}
+
+		paid += tender.Amount
+		if tender.Method == models.PaymentMethodCash {
+			cash += tender.Amount
+		}
+		payments = append(payments, models.Payment{
+			Method:    tender.Method,
+			Amount:    tender.Amount,
+			Reference: tender.Reference,
+		})
+	}
+
+	if paid < total {
+		return nil, 0, 0, fmt.Errorf("%w: total %d, paid %d", ErrInsufficientPayment, total, paid)
+	}
+
+	change := paid - total
+	if change > cash {
+		return nil, 0, 0, ErrNonCashOverpayment
+	}
+
+	return payments, paid, change, nil
+}
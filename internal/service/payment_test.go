@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"kasir-api/internal/models"
+	"testing"
+)
+
+func TestSettlePayments(t *testing.T) {
+	cash := func(amount int) models.Payment {
+		return models.Payment{Method: models.PaymentMethodCash, Amount: amount}
+	}
+	qris := func(amount int) models.Payment {
+		return models.Payment{Method: models.PaymentMethodQRIS, Amount: amount}
+	}
+	debit := func(amount int) models.Payment {
+		return models.Payment{Method: models.PaymentMethodDebit, Amount: amount}
+	}
+
+	tests := []struct {
+		name    string
+		total   int
+		tenders []models.Payment
+		paid    int
+		change  int
+		err     error
+	}{
+		{"exact cash", 47350, []models.Payment{cash(47350)}, 47350, 0, nil},
+		{"cash rounded up to a note", 47350, []models.Payment{cash(50000)}, 50000, 2650, nil},
+		{"cash in several notes", 47350, []models.Payment{cash(20000), cash(20000), cash(10000)}, 50000, 2650, nil},
+		{"exact qris", 47350, []models.Payment{qris(47350)}, 47350, 0, nil},
+		{"qris then cash with change", 47350, []models.Payment{qris(20000), cash(30000)}, 50000, 2650, nil},
+		{"change as large as the cash", 10000, []models.Payment{debit(9000), cash(1000), cash(1000)}, 11000, 1000, nil},
+		{"three tenders exact", 100000, []models.Payment{debit(50000), qris(25000), cash(25000)}, 100000, 0, nil},
+		{"qris overpaid", 47350, []models.Payment{qris(50000)}, 0, 0, ErrNonCashOverpayment},
+		{"change larger than the cash", 10000, []models.Payment{debit(12000), cash(1000)}, 0, 0, ErrNonCashOverpayment},
+		{"non-cash alone over the total", 10000, []models.Payment{qris(6000), debit(6000), cash(500)}, 0, 0, ErrNonCashOverpayment},
+		{"short", 47350, []models.Payment{qris(20000), cash(27000)}, 0, 0, ErrInsufficientPayment},
+		{"unknown method", 1000, []models.Payment{{Method: "cheque", Amount: 1000}}, 0, 0, ErrInvalidPaymentMethod},
+		{"zero tender", 1000, []models.Payment{cash(1000), qris(0)}, 0, 0, ErrInvalidPaymentAmount},
+		{"negative tender", 1000, []models.Payment{cash(2000), cash(-1000)}, 0, 0, ErrInvalidPaymentAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments, paid, change, err := settlePayments(tt.total, tt.tenders)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if paid != tt.paid || change != tt.change {
+				t.Errorf("paid %d change %d, want paid %d change %d", paid, change, tt.paid, tt.change)
+			}
+			if paid-change != tt.total {
+				t.Errorf("paid %d less change %d is not the total %d", paid, change, tt.total)
+			}
+			if len(payments) != len(tt.tenders) {
+				t.Fatalf("%d payments for %d tenders", len(payments), len(tt.tenders))
+			}
+			for i, p := range payments {
+				if p.Method != tt.tenders[i].Method || p.Amount != tt.tenders[i].Amount {
+					t.Errorf("payment %d: %s %d, want %s %d", i, p.Method, p.Amount, tt.tenders[i].Method, tt.tenders[i].Amount)
+				}
+			}
+		})
+	}
+}
+
+func TestSettlePaymentsWithoutTenders(t *testing.T) {
+	payments, paid, change, err := settlePayments(25000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 1 || payments[0].Method != models.PaymentMethodCash || payments[0].Amount != 25000 {
+		t.Errorf("payments %+v, want exact cash", payments)
+	}
+	if paid != 25000 || change != 0 {
+		t.Errorf("paid %d change %d, want paid 25000 change 0", paid, change)
+	}
+
+	payments, paid, change, err = settlePayments(0, nil)
+	if err != nil || len(payments) != 0 || paid != 0 || change != 0 {
+		t.Errorf("free sale: payments %+v paid %d change %d err %v, want nothing", payments, paid, change, err)
+	}
+}
+
+func TestSettlePaymentsKeepsReference(t *testing.T) {
+	tenders := []models.Payment{{Method: models.PaymentMethodDebit, Amount: 5000, Reference: "APPR-0042"}}
+	payments, _, _, err := settlePayments(5000, tenders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payments[0].Reference != "APPR-0042" {
+		t.Errorf("reference %q, want APPR-0042", payments[0].Reference)
+	}
+}
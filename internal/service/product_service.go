@@ -1,42 +1,217 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"kasir-api/internal/models"
 	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"slices"
+	"strings"
+)
+
+const invalidBarcode = "is not a valid EAN-13 or UPC-A barcode"
+
+const (
+	defaultProductPerPage = 50
+	maxProductPerPage     = 200
 )
 
 type ProductService interface {
-	GetAll(name string) []models.Product
-	GetByID(id int) (*models.Product, error)
-	Create(p models.Product) models.Product
-	Update(id int, p models.Product) (*models.Product, error)
-	Delete(id int) error
+	GetAll(ctx context.Context, filter models.ProductFilter) (models.ProductList, error)
+	GetByID(ctx context.Context, id int) (*models.Product, error)
+	GetByBarcode(ctx context.Context, code string) (*models.Product, error)
+	Create(ctx context.Context, p models.Product) (*models.Product, error)
+	Update(ctx context.Context, id int, p models.Product) (*models.Product, error)
+	Delete(ctx context.Context, id int) error
 }
 
 type productService struct {
-	repo repository.ProductRepository
+	repo         repository.ProductRepository
+	categoryRepo repository.CategoriesRepository
+}
+
+func NewProductService(repo repository.ProductRepository, categoryRepo repository.CategoriesRepository) ProductService {
+	return &productService{repo: repo, categoryRepo: categoryRepo}
+}
+
+func (s *productService) GetAll(ctx context.Context, filter models.ProductFilter) (models.ProductList, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultProductPerPage
+	}
+	if filter.PerPage > maxProductPerPage {
+		filter.PerPage = maxProductPerPage
+	}
+
+	products, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return models.ProductList{}, err
+	}
+
+	return models.ProductList{
+		Data:    products,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+func (s *productService) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func NewProductService(repo repository.ProductRepository) ProductService {
-	return &productService{repo: repo}
+// GetByBarcode accepts EAN-13 and UPC-A codes, a UPC-A code matches the
+// EAN-13 barcode it is stored as.
+func (s *productService) GetByBarcode(ctx context.Context, code string) (*models.Product, error) {
+	normalized, ok := models.NormalizeBarcode(code)
+	if !ok {
+		var errs validate.Errors
+		errs.Add("code", invalidBarcode)
+		return nil, errs
+	}
+	p, variantID, err := s.repo.GetByBarcode(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	// A variant barcode answers with just the variant that was scanned
+	if variantID != 0 {
+		if v := p.Variant(variantID); v != nil {
+			p.Variants = []models.ProductVariant{*v}
+		}
+	}
+	return p, nil
+}
+
+func (s *productService) Create(ctx context.Context, p models.Product) (*models.Product, error) {
+	if err := s.validateProduct(ctx, &p); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, p)
 }
 
-func (s *productService) GetAll(name string) []models.Product {
-	return s.repo.GetAll(name)
+func (s *productService) Update(ctx context.Context, id int, p models.Product) (*models.Product, error) {
+	if err := s.validateProduct(ctx, &p); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, p)
 }
 
-func (s *productService) GetByID(id int) (*models.Product, error) {
-	return s.repo.GetByID(id)
+func (s *productService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
 
-func (s *productService) Create(p models.Product) models.Product {
-	return s.repo.Create(p)
+// validateProduct checks the tags on models.Product, the variants against
+// the options, normalizes the barcodes and checks that the category exists.
+// The foreign key still catches a category deleted in between.
+func (s *productService) validateProduct(ctx context.Context, p *models.Product) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.SKU = strings.TrimSpace(p.SKU)
+	errs := validate.Struct(p)
+
+	seen := make(map[string]string)
+	normalizeBarcodes(p.Barcodes, "barcodes", seen, &errs)
+	validateVariants(p, seen, &errs)
+
+	if p.CategoryID > 0 {
+		_, err := s.categoryRepo.GetByID(ctx, p.CategoryID)
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			errs.Add("category_id", "does not refer to an existing category")
+		} else if err != nil {
+			return err
+		}
+	}
+	return errs.Err()
 }
 
-func (s *productService) Update(id int, p models.Product) (*models.Product, error) {
-	return s.repo.Update(id, p)
+// normalizeBarcodes rewrites codes to their stored form. seen maps the codes
+// met so far to their field, so a barcode repeated anywhere in the payload is
+// reported.
+func normalizeBarcodes(codes []string, field string, seen map[string]string, errs *validate.Errors) {
+	for i, code := range codes {
+		name := fmt.Sprintf("%s[%d]", field, i)
+		normalized, ok := models.NormalizeBarcode(code)
+		if !ok {
+			errs.Add(name, invalidBarcode)
+			continue
+		}
+		if first, dup := seen[normalized]; dup {
+			errs.Add(name, "duplicates "+first)
+			continue
+		}
+		seen[normalized] = name
+		codes[i] = normalized
+	}
 }
 
-func (s *productService) Delete(id int) error {
-	return s.repo.Delete(id)
+// validateVariants checks that every variant picks one value of each option
+// and that no two variants pick the same ones.
+func validateVariants(p *models.Product, seenBarcodes map[string]string, errs *validate.Errors) {
+	if len(p.Options) > 0 && len(p.Variants) == 0 {
+		errs.Add("variants", "is required when options are given")
+	}
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		errs.Add("options", "is required when variants are given")
+	}
+
+	optionNames := make(map[string]int)
+	for i := range p.Options {
+		o := &p.Options[i]
+		o.Name = strings.TrimSpace(o.Name)
+		if first, dup := optionNames[o.Name]; dup {
+			errs.Add(fmt.Sprintf("options[%d].name", i), fmt.Sprintf("duplicates options[%d]", first))
+		}
+		optionNames[o.Name] = i
+
+		values := make(map[string]int)
+		for j := range o.Values {
+			o.Values[j] = strings.TrimSpace(o.Values[j])
+			field := fmt.Sprintf("options[%d].values[%d]", i, j)
+			if o.Values[j] == "" {
+				errs.Add(field, "is required")
+			} else if first, dup := values[o.Values[j]]; dup {
+				errs.Add(field, fmt.Sprintf("duplicates options[%d].values[%d]", i, first))
+			}
+			values[o.Values[j]] = j
+		}
+	}
+
+	combinations := make(map[string]int)
+	ids := make(map[int]int)
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.SKU = strings.TrimSpace(v.SKU)
+		field := fmt.Sprintf("variants[%d]", i)
+
+		if v.ID != 0 {
+			if first, dup := ids[v.ID]; dup {
+				errs.Add(field+".id", fmt.Sprintf("duplicates variants[%d]", first))
+			}
+			ids[v.ID] = i
+		}
+
+		normalizeBarcodes(v.Barcodes, field+".barcodes", seenBarcodes, errs)
+
+		if len(v.Options) != len(p.Options) {
+			if len(v.Options) > 0 {
+				errs.Add(field+".options", fmt.Sprintf("must have one value for each of the %d options", len(p.Options)))
+			}
+			continue
+		}
+		for j := range v.Options {
+			v.Options[j] = strings.TrimSpace(v.Options[j])
+			if !slices.Contains(p.Options[j].Values, v.Options[j]) {
+				errs.Add(fmt.Sprintf("%s.options[%d]", field, j), "is not a value of option "+p.Options[j].Name)
+			}
+		}
+		key := strings.Join(v.Options, "\x00")
+		if first, dup := combinations[key]; dup {
+			errs.Add(field+".options", fmt.Sprintf("duplicates variants[%d]", first))
+		}
+		combinations[key] = i
+	}
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"kasir-api/internal/models"
+	"time"
+)
+
+// applyPromotions prices the checkout lines with the promotions running at
+// now. Each line gets at most one line promotion, whichever saves the
+// customer the most. The cart then gets at most one minimum-spend promotion,
+// again the largest, spread over the lines in proportion to what is left of
+// their subtotal. Ties go to the promotion created first.
+//
+// The lines must come in with Subtotal set to UnitPrice * Quantity; they
+// leave with DiscountAmount set and Subtotal reduced by it. The applied cart
+// promotion, if any, is returned.
+func applyPromotions(details []models.TransactionDetail, promotions []models.Promotion, now time.Time) *models.Promotion {
+	var running []models.Promotion
+	for _, p := range promotions {
+		if p.ActiveAt(now) {
+			running = append(running, p)
+		}
+	}
+
+	for i := range details {
+		d := &details[i]
+		var best *models.Promotion
+		bestDiscount := 0
+		for j := range running {
+			p := &running[j]
+			if p.Type == models.PromotionTypeMinSpend || !p.Matches(d.ProductID, d.CategoryID) {
+				continue
+			}
+			if discount := lineDiscount(*p, *d); discount > bestDiscount {
+				best, bestDiscount = p, discount
+			}
+		}
+		if best != nil {
+			d.PromotionID = best.ID
+			d.PromotionName = best.Name
+			d.DiscountAmount = bestDiscount
+			d.Subtotal -= bestDiscount
+		}
+	}
+
+	net := 0
+	for _, d := range details {
+		net += d.Subtotal
+	}
+
+	var cartPromotion *models.Promotion
+	cartDiscount := 0
+	for j := range running {
+		p := &running[j]
+		if p.Type != models.PromotionTypeMinSpend || net < p.MinSpend {
+			continue
+		}
+		if discount := cartDiscountFor(*p, net); discount > cartDiscount {
+			cartPromotion, cartDiscount = p, discount
+		}
+	}
+	if cartPromotion != nil {
+		allocateCartDiscount(details, cartDiscount)
+	}
+	return cartPromotion
+}
+
+func lineDiscount(p models.Promotion, d models.TransactionDetail) int {
+	gross := d.UnitPrice * d.Quantity
+	var discount int
+	switch p.Type {
+	case models.PromotionTypePercentage:
+		discount = gross * p.Percent / 100
+	case models.PromotionTypeFixed:
+		discount = p.Amount * d.Quantity
+	case models.PromotionTypeBuyXGetY:
+		if p.BuyQty > 0 && p.GetQty > 0 {
+			free := d.Quantity / (p.BuyQty + p.GetQty) * p.GetQty
+			discount = free * d.UnitPrice
+		}
+	}
+	return min(discount, gross)
+}
+
+func cartDiscountFor(p models.Promotion, net int) int {
+	discount := p.Amount
+	if discount == 0 {
+		discount = net * p.Percent / 100
+	}
+	return min(discount, net)
+}
+
+// allocateCartDiscount spreads discount over the lines in proportion to their
+// subtotal, so a refunded line gives back what was really paid for it.
+func allocateCartDiscount(details []models.TransactionDetail, discount int) {
+	weights := make([]int, len(details))
+	for i, d := range details {
+		weights[i] = d.Subtotal
+	}
+	for i, share := range allocate(discount, weights) {
+		details[i].DiscountAmount += share
+		details[i].Subtotal -= share
+	}
+}
+
+// allocate splits total in proportion to weights with the largest remainder
+// method: the shares always add up to total exactly and, as long as total
+// does not exceed the sum of the weights, no share exceeds its weight.
+func allocate(total int, weights []int) []int {
+	shares := make([]int, len(weights))
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 {
+		return shares
+	}
+
+	remainders := make([]int, len(weights))
+	allocated := 0
+	for i, w := range weights {
+		shares[i] = total * w / sum
+		remainders[i] = total * w % sum
+		allocated += shares[i]
+	}
+
+	for left := total - allocated; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = -1
+	}
+	return shares
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPromotion = errors.New("invalid promotion")
+)
+
+type PromotionService interface {
+	GetAll(ctx context.Context) ([]models.Promotion, error)
+	GetByID(ctx context.Context, id int) (*models.Promotion, error)
+	Create(ctx context.Context, p models.Promotion) (*models.Promotion, error)
+	Update(ctx context.Context, id int, p models.Promotion) (*models.Promotion, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type promotionService struct {
+	repo repository.PromotionRepository
+}
+
+func NewPromotionService(repo repository.PromotionRepository) PromotionService {
+	return &promotionService{repo: repo}
+}
+
+func (s *promotionService) GetAll(ctx context.Context) ([]models.Promotion, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *promotionService) GetByID(ctx context.Context, id int) (*models.Promotion, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *promotionService) Create(ctx context.Context, p models.Promotion) (*models.Promotion, error) {
+	if err := validatePromotion(&p); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, p)
+}
+
+func (s *promotionService) Update(ctx context.Context, id int, p models.Promotion) (*models.Promotion, error) {
+	if err := validatePromotion(&p); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, p)
+}
+
+func (s *promotionService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func validatePromotion(p *models.Promotion) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	if !p.Type.Valid() {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidPromotion, p.Type)
+	}
+	// A promotion is enabled unless it is switched off explicitly
+	if p.Active == nil {
+		active := true
+		p.Active = &active
+	}
+	if p.Percent < 0 || p.Percent > 100 || p.Amount < 0 || p.BuyQty < 0 || p.GetQty < 0 || p.MinSpend < 0 {
+		return fmt.Errorf("%w: values must not be negative and percent must not exceed 100", ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case models.PromotionTypePercentage:
+		if p.Percent == 0 {
+			return fmt.Errorf("%w: percent is required", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeFixed:
+		if p.Amount == 0 {
+			return fmt.Errorf("%w: amount is required", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeBuyXGetY:
+		if p.BuyQty == 0 || p.GetQty == 0 {
+			return fmt.Errorf("%w: buy_qty and get_qty are required", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeMinSpend:
+		if p.MinSpend == 0 {
+			return fmt.Errorf("%w: min_spend is required", ErrInvalidPromotion)
+		}
+		if (p.Amount == 0) == (p.Percent == 0) {
+			return fmt.Errorf("%w: set either amount or percent", ErrInvalidPromotion)
+		}
+		if p.ProductID != 0 || p.CategoryID != 0 {
+			return fmt.Errorf("%w: min_spend applies to the whole cart", ErrInvalidPromotion)
+		}
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && p.EndsAt.Before(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at is before starts_at", ErrInvalidPromotion)
+	}
+	if (p.StartTime == "") != (p.EndTime == "") {
+		return fmt.Errorf("%w: start_time and end_time must be set together", ErrInvalidPromotion)
+	}
+	for _, clock := range []string{p.StartTime, p.EndTime} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("%w: time %q is not HH:MM", ErrInvalidPromotion, clock)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"kasir-api/internal/models"
+	"testing"
+	"time"
+)
+
+func TestLineDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		promo    models.Promotion
+		price    int
+		quantity int
+		want     int
+	}{
+		{"percentage", models.Promotion{Type: models.PromotionTypePercentage, Percent: 10}, 15000, 3, 4500},
+		{"percentage rounds down", models.Promotion{Type: models.PromotionTypePercentage, Percent: 15}, 999, 1, 149},
+		{"fixed per unit", models.Promotion{Type: models.PromotionTypeFixed, Amount: 2000}, 15000, 3, 6000},
+		{"fixed capped at the price", models.Promotion{Type: models.PromotionTypeFixed, Amount: 20000}, 15000, 2, 30000},
+		{"buy 2 get 1", models.Promotion{Type: models.PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1}, 5000, 7, 10000},
+		{"buy 2 get 1 not reached", models.Promotion{Type: models.PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1}, 5000, 2, 0},
+		{"buy 1 get 1", models.Promotion{Type: models.PromotionTypeBuyXGetY, BuyQty: 1, GetQty: 1}, 5000, 4, 10000},
+		{"cart promotion is no line discount", models.Promotion{Type: models.PromotionTypeMinSpend, Amount: 1000}, 5000, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := models.TransactionDetail{UnitPrice: tt.price, Quantity: tt.quantity}
+			if got := lineDiscount(tt.promo, d); got != tt.want {
+				t.Errorf("lineDiscount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// line is a checkout line as applyPromotions expects it.
+func line(productID, categoryID, price, quantity int) models.TransactionDetail {
+	return models.TransactionDetail{ProductID: productID, CategoryID: categoryID, UnitPrice: price, Quantity: quantity,
+		Subtotal: price * quantity}
+}
+
+func TestApplyPromotionsBestLinePromotion(t *testing.T) {
+	off := false
+	promotions := []models.Promotion{
+		{ID: 1, Type: models.PromotionTypePercentage, Percent: 10},
+		{ID: 2, Type: models.PromotionTypeFixed, Amount: 2000, ProductID: 1},
+		{ID: 3, Type: models.PromotionTypePercentage, Percent: 50, CategoryID: 9},
+		{ID: 4, Type: models.PromotionTypePercentage, Percent: 90, Active: &off},
+		{ID: 5, Type: models.PromotionTypeFixed, Amount: 1500, ProductID: 2},
+	}
+	details := []models.TransactionDetail{
+		line(1, 7, 15000, 2), // 10% = 3000, fixed 2000 a unit = 4000
+		line(2, 7, 15000, 1), // 10% = 1500, fixed = 1500: tie, the first wins
+		line(3, 7, 15000, 1), // only 10%
+	}
+	if cart := applyPromotions(details, promotions, time.Now()); cart != nil {
+		t.Fatalf("unexpected cart promotion %d", cart.ID)
+	}
+
+	want := []struct{ promotion, discount, subtotal int }{
+		{2, 4000, 26000},
+		{1, 1500, 13500},
+		{1, 1500, 13500},
+	}
+	for i, w := range want {
+		d := details[i]
+		if d.PromotionID != w.promotion || d.DiscountAmount != w.discount || d.Subtotal != w.subtotal {
+			t.Errorf("line %d: promotion %d discount %d subtotal %d, want promotion %d discount %d subtotal %d",
+				i, d.PromotionID, d.DiscountAmount, d.Subtotal, w.promotion, w.discount, w.subtotal)
+		}
+	}
+}
+
+func TestApplyPromotionsTimeWindow(t *testing.T) {
+	promotions := []models.Promotion{
+		{ID: 1, Type: models.PromotionTypePercentage, Percent: 20, StartTime: "22:00", EndTime: "02:00"},
+	}
+	for clock, want := range map[string]int{"23:00": 2000, "01:00": 2000, "12:00": 0} {
+		now, _ := time.Parse("15:04", clock)
+		details := []models.TransactionDetail{line(1, 0, 10000, 1)}
+		applyPromotions(details, promotions, now)
+		if details[0].DiscountAmount != want {
+			t.Errorf("at %s: discount %d, want %d", clock, details[0].DiscountAmount, want)
+		}
+	}
+}
+
+func TestApplyPromotionsCartDiscount(t *testing.T) {
+	promotions := []models.Promotion{
+		{ID: 1, Type: models.PromotionTypePercentage, Percent: 10, ProductID: 2},
+		{ID: 2, Type: models.PromotionTypeMinSpend, MinSpend: 10000, Amount: 1000},
+		{ID: 3, Type: models.PromotionTypeMinSpend, MinSpend: 10000, Percent: 10},
+		{ID: 4, Type: models.PromotionTypeMinSpend, MinSpend: 20000, Amount: 5000},
+	}
+	details := []models.TransactionDetail{line(1, 0, 10000, 1), line(2, 0, 5000, 1)}
+
+	// The cart is 14500 after the line promotion: 10% of it beats 1000 off,
+	// and 20000 is not reached
+	cart := applyPromotions(details, promotions, time.Now())
+	if cart == nil || cart.ID != 3 {
+		t.Fatalf("cart promotion %v, want 3", cart)
+	}
+
+	want := []struct{ discount, subtotal int }{{1000, 9000}, {950, 4050}}
+	for i, w := range want {
+		if details[i].DiscountAmount != w.discount || details[i].Subtotal != w.subtotal {
+			t.Errorf("line %d: discount %d subtotal %d, want discount %d subtotal %d",
+				i, details[i].DiscountAmount, details[i].Subtotal, w.discount, w.subtotal)
+		}
+	}
+}
+
+func TestApplyPromotionsMinSpendNotReached(t *testing.T) {
+	promotions := []models.Promotion{
+		{ID: 1, Type: models.PromotionTypePercentage, Percent: 50},
+		{ID: 2, Type: models.PromotionTypeMinSpend, MinSpend: 10000, Amount: 1000},
+	}
+	// 12000 before the line promotion, but only 6000 after it
+	details := []models.TransactionDetail{line(1, 0, 12000, 1)}
+	if cart := applyPromotions(details, promotions, time.Now()); cart != nil {
+		t.Fatalf("cart promotion %d applied below its minimum spend", cart.ID)
+	}
+	if details[0].Subtotal != 6000 {
+		t.Errorf("subtotal %d, want 6000", details[0].Subtotal)
+	}
+}
+
+func TestAllocateCartDiscount(t *testing.T) {
+	details := []models.TransactionDetail{line(1, 0, 3333, 1), line(2, 0, 3333, 1), line(3, 0, 3334, 1)}
+	allocateCartDiscount(details, 1000)
+
+	total := 0
+	for i, want := range []int{333, 333, 334} {
+		d := details[i]
+		if d.DiscountAmount != want || d.Subtotal != d.UnitPrice-want {
+			t.Errorf("line %d: discount %d subtotal %d, want discount %d", i, d.DiscountAmount, d.Subtotal, want)
+		}
+		total += d.DiscountAmount
+	}
+	if total != 1000 {
+		t.Errorf("discounts add up to %d, want 1000", total)
+	}
+}
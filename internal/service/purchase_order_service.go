@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
+)
+
+const (
+	defaultPurchaseOrderPerPage = 20
+	maxPurchaseOrderPerPage     = 100
+)
+
+type PurchaseOrderService interface {
+	GetAll(ctx context.Context, filter models.PurchaseOrderFilter) (models.PurchaseOrderList, error)
+	GetByID(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	Create(ctx context.Context, po models.PurchaseOrder) (*models.PurchaseOrder, error)
+	Update(ctx context.Context, id int, po models.PurchaseOrder) (*models.PurchaseOrder, error)
+	Cancel(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	Receive(ctx context.Context, id int, req models.GoodsReceiptRequest) (*models.PurchaseOrder, error)
+	Outstanding(ctx context.Context, supplierID int) ([]models.OutstandingSupplier, error)
+}
+
+type purchaseOrderService struct {
+	repo         repository.PurchaseOrderRepository
+	productRepo  repository.ProductRepository
+	supplierRepo repository.SupplierRepository
+}
+
+func NewPurchaseOrderService(repo repository.PurchaseOrderRepository, productRepo repository.ProductRepository, supplierRepo repository.SupplierRepository) PurchaseOrderService {
+	return &purchaseOrderService{repo: repo, productRepo: productRepo, supplierRepo: supplierRepo}
+}
+
+func (s *purchaseOrderService) GetAll(ctx context.Context, filter models.PurchaseOrderFilter) (models.PurchaseOrderList, error) {
+	switch filter.Status {
+	case "", models.PurchaseOrderOpen, models.PurchaseOrderPartiallyReceived, models.PurchaseOrderReceived, models.PurchaseOrderCancelled:
+	default:
+		var errs validate.Errors
+		errs.Add("status", "must be open, partially_received, received or cancelled")
+		return models.PurchaseOrderList{}, errs
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultPurchaseOrderPerPage
+	}
+	if filter.PerPage > maxPurchaseOrderPerPage {
+		filter.PerPage = maxPurchaseOrderPerPage
+	}
+
+	orders, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return models.PurchaseOrderList{}, err
+	}
+
+	return models.PurchaseOrderList{
+		Data:    orders,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+func (s *purchaseOrderService) GetByID(ctx context.Context, id int) (*models.PurchaseOrder, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *purchaseOrderService) Create(ctx context.Context, po models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	if err := s.validatePurchaseOrder(ctx, &po); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, &po); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, po.ID)
+}
+
+func (s *purchaseOrderService) Update(ctx context.Context, id int, po models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	if err := s.validatePurchaseOrder(ctx, &po); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, id, &po); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// validatePurchaseOrder checks the order and fills in the product and variant
+// names of its lines. A product with variants is ordered per variant.
+func (s *purchaseOrderService) validatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error {
+	po.Note = strings.TrimSpace(po.Note)
+	errs := validate.Struct(po)
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.supplierRepo.GetByID(ctx, po.SupplierID); err != nil {
+		if errors.Is(err, repository.ErrSupplierNotFound) {
+			errs.Add("supplier_id", "does not refer to an existing supplier")
+			return errs
+		}
+		return err
+	}
+
+	for i := range po.Lines {
+		l := &po.Lines[i]
+		field := fmt.Sprintf("lines[%d]", i)
+
+		product, err := s.productRepo.GetByID(ctx, l.ProductID)
+		if errors.Is(err, repository.ErrProductNotFound) {
+			errs.Add(field+".product_id", "does not refer to an existing product")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		l.ProductName = product.Name
+
+		switch {
+		case l.VariantID == 0 && len(product.Variants) > 0:
+			errs.Add(field+".variant_id", "is required for a product with variants")
+		case l.VariantID != 0 && product.Variant(l.VariantID) == nil:
+			errs.Add(field+".variant_id", fmt.Sprintf("is not a variant of product %d", product.ID))
+		case l.VariantID != 0:
+			l.VariantName = product.Variant(l.VariantID).Name
+		}
+	}
+	return errs.Err()
+}
+
+func (s *purchaseOrderService) Cancel(ctx context.Context, id int) (*models.PurchaseOrder, error) {
+	if err := s.repo.Cancel(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *purchaseOrderService) Receive(ctx context.Context, id int, req models.GoodsReceiptRequest) (*models.PurchaseOrder, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	if err := validate.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Receive(ctx, id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Outstanding reports what suppliers still have to deliver, supplierID 0
+// covers every supplier.
+func (s *purchaseOrderService) Outstanding(ctx context.Context, supplierID int) ([]models.OutstandingSupplier, error) {
+	if supplierID != 0 {
+		if _, err := s.supplierRepo.GetByID(ctx, supplierID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetOutstanding(ctx, supplierID)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
+)
+
+const (
+	defaultMovementPerPage = 50
+	maxMovementPerPage     = 200
+)
+
+type StockMovementService interface {
+	GetAll(ctx context.Context, filter models.StockMovementFilter) (models.StockMovementList, error)
+	Create(ctx context.Context, req models.StockMovementRequest) (*models.StockMovement, error)
+}
+
+type stockMovementService struct {
+	repo        repository.StockMovementRepository
+	productRepo repository.ProductRepository
+}
+
+func NewStockMovementService(repo repository.StockMovementRepository, productRepo repository.ProductRepository) StockMovementService {
+	return &stockMovementService{repo: repo, productRepo: productRepo}
+}
+
+func (s *stockMovementService) GetAll(ctx context.Context, filter models.StockMovementFilter) (models.StockMovementList, error) {
+	if filter.ProductID != 0 {
+		if _, err := s.productRepo.GetByID(ctx, filter.ProductID); err != nil {
+			return models.StockMovementList{}, err
+		}
+	}
+
+	switch filter.Reason {
+	case "", models.StockMovementSale, models.StockMovementRefund, models.StockMovementAdjustment,
+		models.StockMovementPurchase, models.StockMovementTransfer:
+	default:
+		var errs validate.Errors
+		errs.Add("reason", "must be sale, refund, adjustment, purchase or transfer")
+		return models.StockMovementList{}, errs
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultMovementPerPage
+	}
+	if filter.PerPage > maxMovementPerPage {
+		filter.PerPage = maxMovementPerPage
+	}
+
+	movements, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return models.StockMovementList{}, err
+	}
+
+	return models.StockMovementList{
+		Data:    movements,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+// Create posts an adjustment or a transfer. A product with variants moves
+// stock per variant, so it needs variant_id.
+func (s *stockMovementService) Create(ctx context.Context, req models.StockMovementRequest) (*models.StockMovement, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	errs := validate.Struct(req)
+	if req.Quantity == 0 {
+		errs.Add("quantity", "must not be 0")
+	}
+	if req.Reason != models.StockMovementAdjustment && req.Reason != models.StockMovementTransfer {
+		errs.Add("reason", "must be adjustment or transfer")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.VariantID == 0 && len(product.Variants) > 0:
+		errs.Add("variant_id", "is required for a product with variants")
+	case req.VariantID != 0 && product.Variant(req.VariantID) == nil:
+		errs.Add("variant_id", fmt.Sprintf("is not a variant of product %d", product.ID))
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	m := &models.StockMovement{
+		ProductID:   product.ID,
+		VariantID:   req.VariantID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		ReferenceID: req.ReferenceID,
+		Note:        req.Note,
+	}
+	if err := s.repo.Create(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
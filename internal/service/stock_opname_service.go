@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
+)
+
+const (
+	defaultOpnamePerPage = 20
+	maxOpnamePerPage     = 100
+)
+
+type StockOpnameService interface {
+	GetAll(ctx context.Context, filter models.StockOpnameFilter) (models.StockOpnameList, error)
+	GetByID(ctx context.Context, id int, varianceOnly bool) (*models.StockOpname, error)
+	Open(ctx context.Context, req models.StockOpnameRequest) (*models.StockOpname, error)
+	Count(ctx context.Context, id int, req models.StockCountRequest) (*models.StockOpname, error)
+	Post(ctx context.Context, id int) (*models.StockOpname, error)
+	Cancel(ctx context.Context, id int) (*models.StockOpname, error)
+}
+
+type stockOpnameService struct {
+	repo repository.StockOpnameRepository
+}
+
+func NewStockOpnameService(repo repository.StockOpnameRepository) StockOpnameService {
+	return &stockOpnameService{repo: repo}
+}
+
+func (s *stockOpnameService) GetAll(ctx context.Context, filter models.StockOpnameFilter) (models.StockOpnameList, error) {
+	switch filter.Status {
+	case "", models.StockOpnameOpen, models.StockOpnamePosted, models.StockOpnameCancelled:
+	default:
+		var errs validate.Errors
+		errs.Add("status", "must be open, posted or cancelled")
+		return models.StockOpnameList{}, errs
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultOpnamePerPage
+	}
+	if filter.PerPage > maxOpnamePerPage {
+		filter.PerPage = maxOpnamePerPage
+	}
+
+	opnames, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		return models.StockOpnameList{}, err
+	}
+
+	return models.StockOpnameList{
+		Data:    opnames,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+// GetByID returns the session with its lines. With varianceOnly it keeps the
+// counted lines that differ from the expected quantity, the variance report;
+// the summary always covers every line.
+func (s *stockOpnameService) GetByID(ctx context.Context, id int, varianceOnly bool) (*models.StockOpname, error) {
+	o, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if varianceOnly {
+		items := []models.StockOpnameItem{}
+		for _, item := range o.Items {
+			if item.Variance != nil && *item.Variance != 0 {
+				items = append(items, item)
+			}
+		}
+		o.Items = items
+	}
+	return o, nil
+}
+
+func (s *stockOpnameService) Open(ctx context.Context, req models.StockOpnameRequest) (*models.StockOpname, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	if err := validate.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+
+	o := &models.StockOpname{CategoryID: req.CategoryID, Note: req.Note}
+	if err := s.repo.Create(ctx, o); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, o.ID)
+}
+
+func (s *stockOpnameService) Count(ctx context.Context, id int, req models.StockCountRequest) (*models.StockOpname, error) {
+	if err := validate.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetCounts(ctx, id, req.Items); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *stockOpnameService) Post(ctx context.Context, id int) (*models.StockOpname, error) {
+	if err := s.repo.Post(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *stockOpnameService) Cancel(ctx context.Context, id int) (*models.StockOpname, error) {
+	if err := s.repo.Cancel(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"kasir-api/internal/models"
+	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"strings"
+)
+
+type SupplierService interface {
+	GetAll(ctx context.Context) ([]models.Supplier, error)
+	GetByID(ctx context.Context, id int) (*models.Supplier, error)
+	Create(ctx context.Context, s models.Supplier) (*models.Supplier, error)
+	Update(ctx context.Context, id int, s models.Supplier) (*models.Supplier, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type supplierService struct {
+	repo repository.SupplierRepository
+}
+
+func NewSupplierService(repo repository.SupplierRepository) SupplierService {
+	return &supplierService{repo: repo}
+}
+
+func (s *supplierService) GetAll(ctx context.Context) ([]models.Supplier, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *supplierService) GetByID(ctx context.Context, id int) (*models.Supplier, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *supplierService) Create(ctx context.Context, supplier models.Supplier) (*models.Supplier, error) {
+	if err := validateSupplier(&supplier); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, supplier)
+}
+
+func (s *supplierService) Update(ctx context.Context, id int, supplier models.Supplier) (*models.Supplier, error) {
+	if err := validateSupplier(&supplier); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, supplier)
+}
+
+func (s *supplierService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func validateSupplier(s *models.Supplier) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.ContactName = strings.TrimSpace(s.ContactName)
+	s.Phone = strings.TrimSpace(s.Phone)
+	s.Email = strings.TrimSpace(s.Email)
+	s.Address = strings.TrimSpace(s.Address)
+
+	errs := validate.Struct(s)
+	if s.Email != "" && !strings.Contains(s.Email, "@") {
+		errs.Add("email", "is not an email address")
+	}
+	return errs.Err()
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"kasir-api/internal/models"
+	"math"
+)
+
+// TaxConfig is the outlet tax rule and service charge, rates in percent.
+type TaxConfig struct {
+	TaxRate           float64
+	TaxInclusive      bool
+	ServiceChargeRate float64
+}
+
+// taxRule resolves the tax rule of a product: its category's rule where the
+// category has one, the outlet rule otherwise.
+func (c TaxConfig) taxRule(category *models.Category) (float64, bool) {
+	rate, inclusive := c.TaxRate, c.TaxInclusive
+	if category != nil {
+		if category.TaxRate != nil {
+			rate = *category.TaxRate
+		}
+		if category.TaxInclusive != nil {
+			inclusive = *category.TaxInclusive
+		}
+	}
+	return rate, inclusive
+}
+
+type taxGroup struct {
+	basisPoints int
+	inclusive   bool
+}
+
+// applyCharges computes tax and service charge on lines that already carry
+// their discounted Subtotal and their TaxRate/TaxInclusive rule.
+//
+// Rounding is done once per transaction and tax rule, half up to the rupiah,
+// and the result is then spread over the lines of that rule in proportion to
+// their subtotal. For an exclusive rule the tax is Subtotal * rate; for an
+// inclusive rule it is the part of Subtotal above Subtotal / (1 + rate).
+//
+// The service charge is serviceChargeRate of the total tax base (DPP), rounded
+// the same way, and is not taxed itself.
+func applyCharges(details []models.TransactionDetail, serviceChargeRate float64) {
+	groups := make(map[taxGroup][]int)
+	var order []taxGroup
+	for i, d := range details {
+		g := taxGroup{basisPoints: basisPoints(d.TaxRate), inclusive: d.TaxInclusive}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	for _, g := range order {
+		lines := groups[g]
+		weights := make([]int, len(lines))
+		net := 0
+		for j, i := range lines {
+			weights[j] = details[i].Subtotal
+			net += details[i].Subtotal
+		}
+
+		var tax int
+		if g.inclusive {
+			tax = net - roundHalfUp(net*10000, 10000+g.basisPoints)
+		} else {
+			tax = roundHalfUp(net*g.basisPoints, 10000)
+		}
+
+		for j, share := range allocate(tax, weights) {
+			d := &details[lines[j]]
+			d.TaxAmount = share
+			d.TaxBase = d.Subtotal
+			if g.inclusive {
+				d.TaxBase -= share
+			}
+		}
+	}
+
+	weights := make([]int, len(details))
+	base := 0
+	for i, d := range details {
+		weights[i] = d.TaxBase
+		base += d.TaxBase
+	}
+	serviceCharge := roundHalfUp(base*basisPoints(serviceChargeRate), 10000)
+	for i, share := range allocate(serviceCharge, weights) {
+		details[i].ServiceCharge = share
+	}
+}
+
+// basisPoints turns a percentage such as 11 or 2.5 into hundredths of a percent.
+func basisPoints(rate float64) int {
+	return int(math.Round(rate * 100))
+}
+
+// roundHalfUp divides a non-negative a by b, rounding halves up.
+func roundHalfUp(a, b int) int {
+	return (2*a + b) / (2 * b)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"kasir-api/internal/models"
+	"testing"
+)
+
+func TestRoundHalfUp(t *testing.T) {
+	tests := []struct {
+		a, b, want int
+	}{
+		{0, 7, 0},
+		{4, 3, 1},
+		{5, 3, 2},
+		{3, 2, 2},
+		{5, 2, 3},
+		{16501100, 10000, 1650},
+		{16505000, 10000, 1651},
+		{16504999, 10000, 1650},
+	}
+	for _, tt := range tests {
+		if got := roundHalfUp(tt.a, tt.b); got != tt.want {
+			t.Errorf("roundHalfUp(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		weights []int
+		want    []int
+	}{
+		{"exact split", 100, []int{1, 1, 2}, []int{25, 25, 50}},
+		{"remainder to the largest fraction", 1650, []int{10000, 5001}, []int{1100, 550}},
+		{"remainders spread one each", 10, []int{1, 1, 1}, []int{4, 3, 3}},
+		{"nothing to allocate", 0, []int{5, 7}, []int{0, 0}},
+		{"zero weights", 10, []int{0, 0}, []int{0, 0}},
+		{"zero weight gets nothing", 9, []int{0, 3, 6}, []int{0, 3, 6}},
+		{"single line takes all", 777, []int{3}, []int{777}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.total, tt.weights)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateAddsUpToTotal(t *testing.T) {
+	weightSets := [][]int{
+		{1, 1, 1},
+		{3, 7, 11, 13},
+		{10000, 5001, 2499},
+		{1, 999999},
+		{2, 2, 2, 2, 2, 2, 2},
+	}
+	for _, weights := range weightSets {
+		sum := 0
+		for _, w := range weights {
+			sum += w
+		}
+		for total := 0; total <= sum && total <= 5000; total++ {
+			got := 0
+			for i, share := range allocate(total, weights) {
+				if share < 0 || share > weights[i] {
+					t.Fatalf("allocate(%d, %v): share %d of weight %d out of range", total, weights, share, weights[i])
+				}
+				got += share
+			}
+			if got != total {
+				t.Fatalf("allocate(%d, %v) adds up to %d", total, weights, got)
+			}
+		}
+	}
+}
+
+func TestApplyChargesExclusive(t *testing.T) {
+	details := []models.TransactionDetail{
+		{Subtotal: 10000, TaxRate: 11},
+		{Subtotal: 5001, TaxRate: 11},
+	}
+	applyCharges(details, 0)
+
+	// 11% of 15001 is 1650.11, rounded once for the rule and then spread
+	want := []struct{ base, tax int }{{10000, 1100}, {5001, 550}}
+	for i, w := range want {
+		if details[i].TaxBase != w.base || details[i].TaxAmount != w.tax {
+			t.Errorf("line %d: base %d tax %d, want base %d tax %d", i, details[i].TaxBase, details[i].TaxAmount, w.base, w.tax)
+		}
+	}
+}
+
+func TestApplyChargesInclusive(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtotals []int
+		bases     []int
+		taxes     []int
+	}{
+		{"whole rupiah", []int{11100}, []int{10000}, []int{1100}},
+		// 10000 / 1.11 = 9009.009
+		{"rounded base", []int{10000}, []int{9009}, []int{991}},
+		// 15000 / 1.11 = 13513.51, tax 1486 spread 2:1
+		{"spread over lines", []int{10000, 5000}, []int{9009, 4505}, []int{991, 495}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := make([]models.TransactionDetail, len(tt.subtotals))
+			for i, s := range tt.subtotals {
+				details[i] = models.TransactionDetail{Subtotal: s, TaxRate: 11, TaxInclusive: true}
+			}
+			applyCharges(details, 0)
+
+			for i, d := range details {
+				if d.TaxBase != tt.bases[i] || d.TaxAmount != tt.taxes[i] {
+					t.Errorf("line %d: base %d tax %d, want base %d tax %d", i, d.TaxBase, d.TaxAmount, tt.bases[i], tt.taxes[i])
+				}
+				if d.TaxBase+d.TaxAmount != d.Subtotal {
+					t.Errorf("line %d: base %d + tax %d does not split subtotal %d", i, d.TaxBase, d.TaxAmount, d.Subtotal)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyChargesMixedRules(t *testing.T) {
+	details := []models.TransactionDetail{
+		{Subtotal: 11100, TaxRate: 11, TaxInclusive: true},
+		{Subtotal: 10000, TaxRate: 11},
+		{Subtotal: 5000, TaxRate: 0},
+	}
+	applyCharges(details, 0)
+
+	want := []struct{ base, tax int }{{10000, 1100}, {10000, 1100}, {5000, 0}}
+	for i, w := range want {
+		if details[i].TaxBase != w.base || details[i].TaxAmount != w.tax {
+			t.Errorf("line %d: base %d tax %d, want base %d tax %d", i, details[i].TaxBase, details[i].TaxAmount, w.base, w.tax)
+		}
+	}
+}
+
+// The service charge is worked out on the tax base before tax, and is not
+// taxed itself.
+func TestApplyChargesServiceChargeOnBaseBeforeTax(t *testing.T) {
+	tests := []struct {
+		name          string
+		detail        models.TransactionDetail
+		rate          float64
+		tax           int
+		serviceCharge int
+	}{
+		{"exclusive", models.TransactionDetail{Subtotal: 10000, TaxRate: 11}, 10, 1100, 1000},
+		{"inclusive", models.TransactionDetail{Subtotal: 11100, TaxRate: 11, TaxInclusive: true}, 5, 1100, 500},
+		{"untaxed", models.TransactionDetail{Subtotal: 10000}, 2.5, 0, 250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := []models.TransactionDetail{tt.detail}
+			applyCharges(details, tt.rate)
+			if details[0].TaxAmount != tt.tax || details[0].ServiceCharge != tt.serviceCharge {
+				t.Errorf("tax %d service charge %d, want tax %d service charge %d",
+					details[0].TaxAmount, details[0].ServiceCharge, tt.tax, tt.serviceCharge)
+			}
+		})
+	}
+}
+
+func TestApplyChargesServiceChargeAddsUp(t *testing.T) {
+	details := []models.TransactionDetail{
+		{Subtotal: 3333, TaxRate: 11},
+		{Subtotal: 3333, TaxRate: 11},
+		{Subtotal: 3334, TaxRate: 11},
+	}
+	applyCharges(details, 7.5)
+
+	// 7.5% of 10000
+	total := 0
+	for _, d := range details {
+		total += d.ServiceCharge
+	}
+	if total != 750 {
+		t.Errorf("service charge adds up to %d, want 750", total)
+	}
+}
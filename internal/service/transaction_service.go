@@ -1,75 +1,405 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"kasir-api/internal/models"
 	"kasir-api/internal/repository"
+	"kasir-api/internal/validate"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	defaultTransactionPerPage = 20
+	maxTransactionPerPage     = 100
+	defaultReportTop          = 10
+	maxReportTop              = 100
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different payload")
+	ErrRefundReasonRequired  = errors.New("reason is required")
+	ErrRefundItemsRequired   = errors.New("at least one item must be refunded")
+	ErrInvalidRefundQuantity = errors.New("refund quantity must be greater than zero")
+)
+
 type TransactionService interface {
-	Checkout(items []models.CheckoutItem) (*models.Transaction, error)
-	GetDailyReport() (models.SalesReport, error)
-	GetReport(startDate, endDate time.Time) (models.SalesReport, error)
+	Checkout(ctx context.Context, req models.CheckoutRequest) (*models.Transaction, error)
+	GetTransactions(ctx context.Context, filter models.TransactionFilter) (models.TransactionList, error)
+	EachTransaction(ctx context.Context, filter models.TransactionFilter, fn func(models.Transaction) error) error
+	GetTransaction(ctx context.Context, id int) (*models.Transaction, error)
+	VoidTransaction(ctx context.Context, id int, req models.VoidRequest) (*models.Refund, error)
+	RefundTransaction(ctx context.Context, id int, req models.RefundRequest) (*models.Refund, error)
+	GetDailyReport(ctx context.Context, opts models.ReportOptions) (models.SalesReport, error)
+	GetReport(ctx context.Context, startDate, endDate time.Time, opts models.ReportOptions) (models.SalesReport, error)
 }
 
 type transactionService struct {
-	repo        repository.TransactionRepository
-	productRepo repository.ProductRepository
+	repo          repository.TransactionRepository
+	productRepo   repository.ProductRepository
+	promotionRepo repository.PromotionRepository
+	tax           TaxConfig
+	days          BusinessDay
 }
 
-func NewTransactionService(repo repository.TransactionRepository, productRepo repository.ProductRepository) TransactionService {
-	return &transactionService{repo: repo, productRepo: productRepo}
+// NewTransactionService returns the service. days decides which business day
+// a report date covers and the clock promotion time windows are read on.
+func NewTransactionService(repo repository.TransactionRepository, productRepo repository.ProductRepository, promotionRepo repository.PromotionRepository, tax TaxConfig, days BusinessDay) TransactionService {
+	return &transactionService{repo: repo, productRepo: productRepo, promotionRepo: promotionRepo, tax: tax, days: days}
 }
 
-func (s *transactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	var totalAmount int
-	var details []models.TransactionDetail
+func (s *transactionService) Checkout(ctx context.Context, req models.CheckoutRequest) (*models.Transaction, error) {
+	if err := s.resolveBarcodes(ctx, req.Items); err != nil {
+		return nil, err
+	}
+	if err := validate.Struct(req).Err(); err != nil {
+		return nil, err
+	}
 
-	for _, item := range items {
-		product, err := s.productRepo.GetByID(item.ProductID)
-		if err != nil {
-			return nil, errors.New("product not found with ID " + string(rune(item.ProductID)))
-		}
+	hash := checkoutHash(req)
 
-		if product.Stock < item.Quantity {
-			return nil, errors.New("insufficient stock for product " + product.Name)
+	// A retried request gets the transaction created by the first attempt
+	if req.IdempotencyKey != "" {
+		existing, err := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err == nil {
+			return replay(existing, hash)
 		}
+		if !errors.Is(err, repository.ErrTransactionNotFound) {
+			return nil, err
+		}
+	}
 
-		subtotal := product.Price * item.Quantity
-		totalAmount += subtotal
+	var details []models.TransactionDetail
+	var errs validate.Errors
 
-		details = append(details, models.TransactionDetail{
+	for i, item := range req.Items {
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("product id %d: %w", item.ProductID, err)
+		}
+
+		detail := models.TransactionDetail{
 			ProductID:   product.ID,
 			ProductName: product.Name,
+			CategoryID:  product.CategoryID,
+			UnitPrice:   product.Price,
 			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
-		})
+		}
+
+		// Products with variants are sold per variant, at the variant's price
+		field := fmt.Sprintf("items[%d].variant_id", i)
+		switch {
+		case item.VariantID == 0 && len(product.Variants) > 0:
+			errs.Add(field, "is required for a product with variants")
+			continue
+		case item.VariantID != 0:
+			variant := product.Variant(item.VariantID)
+			if variant == nil {
+				errs.Add(field, fmt.Sprintf("is not a variant of product %d", product.ID))
+				continue
+			}
+			detail.VariantID = variant.ID
+			detail.VariantName = variant.Name
+			detail.UnitPrice = variant.Price
+		}
+
+		// Stock is validated and decremented by the repository while the
+		// product rows are locked, a check here would race with other cashiers.
+		detail.Subtotal = detail.UnitPrice * item.Quantity
+
+		if product.Category != nil {
+			detail.CategoryName = product.Category.Name
+		}
+		detail.TaxRate, detail.TaxInclusive = s.tax.taxRule(product.Category)
+		details = append(details, detail)
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	now := s.days.Now()
+	promotions, err := s.promotionRepo.GetActive(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	cartPromotion := applyPromotions(details, promotions, now)
+	applyCharges(details, s.tax.ServiceChargeRate)
+
+	var grossAmount, discountAmount, subtotal, taxAmount, serviceCharge, totalAmount int
+	for _, d := range details {
+		grossAmount += d.UnitPrice * d.Quantity
+		discountAmount += d.DiscountAmount
+		subtotal += d.Subtotal
+		taxAmount += d.TaxAmount
+		serviceCharge += d.ServiceCharge
+		totalAmount += d.Subtotal + d.ServiceCharge
+		if !d.TaxInclusive {
+			totalAmount += d.TaxAmount
+		}
+	}
+
+	payments, paid, change, err := settlePayments(totalAmount, req.Payments)
+	if err != nil {
+		return nil, err
 	}
 
 	transaction := &models.Transaction{
-		TotalAmount: totalAmount,
-		Details:     details,
+		GrossAmount:    grossAmount,
+		DiscountAmount: discountAmount,
+		Subtotal:       subtotal,
+		TaxAmount:      taxAmount,
+		ServiceCharge:  serviceCharge,
+		TotalAmount:    totalAmount,
+		PaidAmount:     paid,
+		Change:         change,
+		IdempotencyKey: req.IdempotencyKey,
+		RequestHash:    hash,
+		Details:        details,
+		Payments:       payments,
+	}
+	if cartPromotion != nil {
+		transaction.CartPromotionID = cartPromotion.ID
+		transaction.CartPromotion = cartPromotion.Name
 	}
 
-	err := s.repo.CreateTransaction(transaction)
+	err = s.repo.CreateTransaction(ctx, transaction)
 	if err != nil {
+		// Lost the race against a concurrent retry of the same request
+		if errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+			existing, err := s.repo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
+			if err != nil {
+				return nil, err
+			}
+			return replay(existing, hash)
+		}
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (s *transactionService) GetDailyReport() (models.SalesReport, error) {
-	now := time.Now()
-	// Set time to beginning of the day (00:00:00)
-	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	// Set time to end of the day (23:59:59)
-	endDate := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, now.Location())
+// replay returns the transaction stored under an idempotency key, provided
+// it was created from the same payload.
+func replay(existing *models.Transaction, hash string) (*models.Transaction, error) {
+	if existing.RequestHash != hash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return existing, nil
+}
 
-	return s.repo.GetSalesSummary(startDate, endDate)
+// resolveBarcodes fills in the product, and the variant for a variant
+// barcode, of every item that was scanned rather than picked by id. An item
+// carrying both has to name the same product and variant.
+func (s *transactionService) resolveBarcodes(ctx context.Context, items []models.CheckoutItem) error {
+	var errs validate.Errors
+	for i := range items {
+		if items[i].Barcode == "" {
+			continue
+		}
+		field := fmt.Sprintf("items[%d].barcode", i)
+		code, ok := models.NormalizeBarcode(items[i].Barcode)
+		if !ok {
+			errs.Add(field, invalidBarcode)
+			continue
+		}
+
+		product, variantID, err := s.productRepo.GetByBarcode(ctx, code)
+		if err != nil {
+			return fmt.Errorf("barcode %s: %w", code, err)
+		}
+		if items[i].ProductID != 0 && items[i].ProductID != product.ID {
+			errs.Add(field, fmt.Sprintf("belongs to product %d, not product_id %d", product.ID, items[i].ProductID))
+			continue
+		}
+		if variantID != 0 && items[i].VariantID != 0 && items[i].VariantID != variantID {
+			errs.Add(field, fmt.Sprintf("belongs to variant %d, not variant_id %d", variantID, items[i].VariantID))
+			continue
+		}
+		items[i].ProductID = product.ID
+		if variantID != 0 {
+			items[i].VariantID = variantID
+		}
+	}
+	return errs.Err()
 }
 
-func (s *transactionService) GetReport(startDate, endDate time.Time) (models.SalesReport, error) {
-	return s.repo.GetSalesSummary(startDate, endDate)
+// checkoutHash fingerprints the checkout payload so a reused idempotency key
+// can be told apart from a genuine retry. Items are merged per product and
+// variant and sorted, so a retry that lists the same items in another order
+// still matches.
+// Tenders are kept in the order they were handed over.
+func checkoutHash(req models.CheckoutRequest) string {
+	type line struct{ product, variant int }
+	quantities := make(map[line]int)
+	for _, item := range req.Items {
+		quantities[line{item.ProductID, item.VariantID}] += item.Quantity
+	}
+	lines := make([]line, 0, len(quantities))
+	for l := range quantities {
+		lines = append(lines, l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].product != lines[j].product {
+			return lines[i].product < lines[j].product
+		}
+		return lines[i].variant < lines[j].variant
+	})
+
+	var b strings.Builder
+	for _, l := range lines {
+		// Lines without a variant hash as they did before variants existed
+		b.WriteString(strconv.Itoa(l.product))
+		if l.variant != 0 {
+			b.WriteString("/" + strconv.Itoa(l.variant))
+		}
+		b.WriteString(":" + strconv.Itoa(quantities[l]) + ";")
+	}
+	b.WriteString("|")
+	for _, p := range req.Payments {
+		b.WriteString(string(p.Method) + ":" + strconv.Itoa(p.Amount) + ":" + p.Reference + ";")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *transactionService) GetTransactions(ctx context.Context, filter models.TransactionFilter) (models.TransactionList, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultTransactionPerPage
+	}
+	if filter.PerPage > maxTransactionPerPage {
+		filter.PerPage = maxTransactionPerPage
+	}
+
+	transactions, total, err := s.repo.GetAll(ctx, s.businessDays(filter))
+	if err != nil {
+		return models.TransactionList{}, err
+	}
+
+	return models.TransactionList{
+		Data:    transactions,
+		Total:   total,
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+	}, nil
+}
+
+// EachTransaction walks every transaction matching the filter for an export,
+// the paging of the filter is ignored.
+func (s *transactionService) EachTransaction(ctx context.Context, filter models.TransactionFilter, fn func(models.Transaction) error) error {
+	return s.repo.Each(ctx, s.businessDays(filter), fn)
+}
+
+// businessDays turns the dates of the filter into the business days they
+// stand for: StartDate becomes the start of its day and EndDate the end of
+// its own, which the repository takes as exclusive.
+func (s *transactionService) businessDays(filter models.TransactionFilter) models.TransactionFilter {
+	if filter.StartDate != nil {
+		start := s.days.Start(*filter.StartDate)
+		filter.StartDate = &start
+	}
+	if filter.EndDate != nil {
+		_, end := s.days.Range(*filter.EndDate, *filter.EndDate)
+		filter.EndDate = &end
+	}
+	return filter
+}
+
+func (s *transactionService) GetTransaction(ctx context.Context, id int) (*models.Transaction, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *transactionService) VoidTransaction(ctx context.Context, id int, req models.VoidRequest) (*models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrRefundReasonRequired
+	}
+
+	refund := &models.Refund{
+		TransactionID: id,
+		Type:          models.RefundTypeVoid,
+		Reason:        strings.TrimSpace(req.Reason),
+	}
+	if err := s.repo.CreateRefund(ctx, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *transactionService) RefundTransaction(ctx context.Context, id int, req models.RefundRequest) (*models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrRefundReasonRequired
+	}
+	if len(req.Items) == 0 {
+		return nil, ErrRefundItemsRequired
+	}
+
+	items := make([]models.RefundItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidRefundQuantity
+		}
+		items = append(items, models.RefundItem{
+			TransactionDetailID: item.TransactionDetailID,
+			Quantity:            item.Quantity,
+		})
+	}
+
+	refund := &models.Refund{
+		TransactionID: id,
+		Type:          models.RefundTypeRefund,
+		Reason:        strings.TrimSpace(req.Reason),
+		Items:         items,
+	}
+	if err := s.repo.CreateRefund(ctx, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// GetDailyReport reports the business day that is running now.
+func (s *transactionService) GetDailyReport(ctx context.Context, opts models.ReportOptions) (models.SalesReport, error) {
+	today := s.days.Date(time.Now())
+	return s.GetReport(ctx, today, today, opts)
+}
+
+// GetReport reports the business days from startDate to endDate, both
+// included; only the dates of the two count.
+func (s *transactionService) GetReport(ctx context.Context, startDate, endDate time.Time, opts models.ReportOptions) (models.SalesReport, error) {
+	var errs validate.Errors
+	for _, section := range opts.Include {
+		switch section {
+		case models.ReportTopProducts, models.ReportCategories, models.ReportHeatmap, models.ReportPayments, models.ReportBasket:
+		default:
+			errs.Add("include", fmt.Sprintf("unknown section %q, use products, categories, heatmap, payments or basket", section))
+		}
+	}
+	if opts.Top < 0 {
+		errs.Add("top", "must be at least 1")
+	}
+	if err := errs.Err(); err != nil {
+		return models.SalesReport{}, err
+	}
+
+	if opts.Top == 0 {
+		opts.Top = defaultReportTop
+	}
+	if opts.Top > maxReportTop {
+		opts.Top = maxReportTop
+	}
+
+	start, end := s.days.Range(startDate, endDate)
+	report, err := s.repo.GetSalesSummary(ctx, start, end, opts)
+	if err != nil {
+		return report, err
+	}
+	report.PeriodStart, report.PeriodEnd = start, end
+	return report, nil
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/json"
+	"kasir-api/internal/models"
+	"testing"
+)
+
+func decodeCheckout(t *testing.T, body string) models.CheckoutRequest {
+	t.Helper()
+	var req models.CheckoutRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+const checkoutBody = `{
+	"idempotency_key": "till-1-0001",
+	"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+	"payments": [{"method": "cash", "amount": 50000}]
+}`
+
+func TestCheckoutHashSameBody(t *testing.T) {
+	first := checkoutHash(decodeCheckout(t, checkoutBody))
+	if again := checkoutHash(decodeCheckout(t, checkoutBody)); again != first {
+		t.Errorf("same body hashed as %s and %s", first, again)
+	}
+
+	// Hashes stored by earlier versions must still match a retry
+	if want := "02bbfa8147fad41579fc836a61bc53ea1a9eb49d4c1a82d9824fa40e52fea4cf"; first != want {
+		t.Errorf("hash %s, want %s", first, want)
+	}
+
+	equivalent := map[string]string{
+		"items in another order": `{"idempotency_key": "till-1-0001",
+			"items": [{"product_id": 3, "quantity": 2}, {"product_id": 7, "quantity": 1}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"other idempotency key": `{"idempotency_key": "till-1-0002",
+			"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"explicit no variant": `{"idempotency_key": "till-1-0001",
+			"items": [{"product_id": 7, "variant_id": 0, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+	}
+	for name, body := range equivalent {
+		if got := checkoutHash(decodeCheckout(t, body)); got != first {
+			t.Errorf("%s: hash %s, want %s", name, got, first)
+		}
+	}
+}
+
+func TestCheckoutHashChangedBody(t *testing.T) {
+	base := checkoutHash(decodeCheckout(t, checkoutBody))
+
+	changed := map[string]string{
+		"quantity": `{"items": [{"product_id": 7, "quantity": 2}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"product": `{"items": [{"product_id": 8, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"variant": `{"items": [{"product_id": 7, "variant_id": 1, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"extra item": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}, {"product_id": 9, "quantity": 1}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"item left out": `{"items": [{"product_id": 7, "quantity": 1}],
+			"payments": [{"method": "cash", "amount": 50000}]}`,
+		"payment amount": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 100000}]}`,
+		"payment method": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "qris", "amount": 50000}]}`,
+		"payment reference": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 50000, "reference": "R1"}]}`,
+		"split tender": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}],
+			"payments": [{"method": "cash", "amount": 25000}, {"method": "cash", "amount": 25000}]}`,
+		"no payments": `{"items": [{"product_id": 7, "quantity": 1}, {"product_id": 3, "quantity": 2}]}`,
+	}
+	seen := map[string]string{base: "original"}
+	for name, body := range changed {
+		got := checkoutHash(decodeCheckout(t, body))
+		if other, ok := seen[got]; ok {
+			t.Errorf("%s: hashes the same as %s", name, other)
+		}
+		seen[got] = name
+	}
+}
+
+func TestCheckoutHashTenderOrder(t *testing.T) {
+	a := checkoutHash(decodeCheckout(t, `{"items": [{"product_id": 1, "quantity": 1}],
+		"payments": [{"method": "qris", "amount": 20000}, {"method": "cash", "amount": 30000}]}`))
+	b := checkoutHash(decodeCheckout(t, `{"items": [{"product_id": 1, "quantity": 1}],
+		"payments": [{"method": "cash", "amount": 30000}, {"method": "qris", "amount": 20000}]}`))
+	if a == b {
+		t.Error("tenders in another order hash the same")
+	}
+}
@@ -0,0 +1,198 @@
+// Package validate checks structs against rules declared in `validate` tags
+// and reports every violation at once, named after the JSON fields.
+//
+// Rules are separated by commas:
+//
+//	required    not the zero value; strings must not be blank, slices not empty
+//	min=N       numbers at least N, strings and slices at least N long
+//	max=N       numbers at most N, strings and slices at most N long
+//	gt=N        numbers greater than N
+//	dive        validate every element of a slice of structs
+//	unique=F    no two elements of a slice share the same value in field F,
+//	            F+G compares several fields together
+//
+// Nil pointers skip every rule but required, non-nil ones are checked against
+// the value they point to.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is returned by Struct when at least one rule fails.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + " " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add appends a violation found outside the tags, such as a reference that
+// has to be looked up.
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Err returns e as an error, or nil when there is nothing in it.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Struct validates v, a struct or a pointer to one, and returns the
+// violations for the caller to extend with its own checks before calling Err.
+// A malformed tag panics, it is a programming error.
+func Struct(v interface{}) Errors {
+	var errs Errors
+	checkStruct(reflect.Indirect(reflect.ValueOf(v)), "", &errs)
+	return errs
+}
+
+func checkStruct(v reflect.Value, prefix string, errs *Errors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+		checkField(v.Field(i), prefix+jsonName(sf), tag, errs)
+	}
+}
+
+func checkField(v reflect.Value, name, tag string, errs *Errors) {
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(rule, "=")
+
+		if key == "required" {
+			if isBlank(v) {
+				errs.Add(name, "is required")
+				return
+			}
+			continue
+		}
+
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+
+		var msg string
+		switch key {
+		case "min":
+			if n := number(arg); measure(v) < n {
+				msg = boundMessage(v, "at least", arg)
+			}
+		case "max":
+			if n := number(arg); measure(v) > n {
+				msg = boundMessage(v, "at most", arg)
+			}
+		case "gt":
+			if n := number(arg); measure(v) <= n {
+				msg = "must be greater than " + arg
+			}
+		case "dive":
+			for j := 0; j < v.Len(); j++ {
+				checkStruct(reflect.Indirect(v.Index(j)), fmt.Sprintf("%s[%d].", name, j), errs)
+			}
+		case "unique":
+			fields := strings.Split(arg, "+")
+			seen := make(map[string]int)
+			for j := 0; j < v.Len(); j++ {
+				elem := reflect.Indirect(v.Index(j))
+				values := make([]interface{}, len(fields))
+				for k, field := range fields {
+					sf, ok := elem.Type().FieldByName(field)
+					if !ok {
+						panic("validate: unique on unknown field " + field)
+					}
+					values[k] = elem.FieldByIndex(sf.Index).Interface()
+				}
+				// The error points at the first field
+				sf, _ := elem.Type().FieldByName(fields[0])
+				value := fmt.Sprintf("%#v", values)
+				if first, dup := seen[value]; dup {
+					errs.Add(fmt.Sprintf("%s[%d].%s", name, j, jsonName(sf)),
+						fmt.Sprintf("duplicates %s[%d]", name, first))
+					continue
+				}
+				seen[value] = j
+			}
+		default:
+			panic("validate: unknown rule " + key)
+		}
+
+		if msg != "" {
+			errs.Add(name, msg)
+		}
+	}
+}
+
+func isBlank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// measure is the value of a number and the length of anything else.
+func measure(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return float64(len([]rune(v.String())))
+	default:
+		return float64(v.Len())
+	}
+}
+
+func boundMessage(v reflect.Value, bound, arg string) string {
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", bound, arg)
+	case reflect.Slice, reflect.Map:
+		return fmt.Sprintf("must have %s %s items", bound, arg)
+	default:
+		return fmt.Sprintf("must be %s %s", bound, arg)
+	}
+}
+
+func number(arg string) float64 {
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validate: bad number " + arg)
+	}
+	return n
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,178 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type line struct {
+	ProductID int `json:"product_id" validate:"gt=0"`
+	VariantID int `json:"variant_id" validate:"min=0"`
+	Quantity  int `json:"quantity" validate:"gt=0,max=999"`
+}
+
+type order struct {
+	Name     string   `json:"name" validate:"required,max=5"`
+	Code     string   `json:"code,omitempty" validate:"min=2"`
+	Price    *int     `json:"price" validate:"required,min=0"`
+	Discount *float64 `json:"discount" validate:"min=0,max=100"`
+	Limit    *int     `json:"limit" validate:"gt=0"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Lines    []line   `json:"lines" validate:"required,dive,unique=ProductID+VariantID"`
+	Internal string   `validate:"required"`
+	Ignored  string   `json:"ignored"`
+}
+
+func intp(n int) *int           { return &n }
+func floatp(f float64) *float64 { return &f }
+
+// valid is an order that passes every rule.
+func valid() order {
+	return order{Name: "Kopi", Code: "KP", Price: intp(0), Lines: []line{{ProductID: 1, Quantity: 1}}, Internal: "x"}
+}
+
+// check runs Struct on the order valid() returns after change has edited it.
+func check(t *testing.T, change func(o *order)) Errors {
+	t.Helper()
+	o := valid()
+	change(&o)
+	return Struct(&o)
+}
+
+func TestValidStruct(t *testing.T) {
+	if errs := Struct(valid()); errs.Err() != nil {
+		t.Fatalf("valid order: %v", errs)
+	}
+	if errs := Struct(&order{}); len(errs) == 0 {
+		t.Fatal("empty order passed through a pointer")
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(o *order)
+		want   Errors
+	}{
+		{"required string", func(o *order) { o.Name = "" }, Errors{{"name", "is required"}}},
+		{"required blank string", func(o *order) { o.Name = "   " }, Errors{{"name", "is required"}}},
+		{"required nil pointer", func(o *order) { o.Price = nil }, Errors{{"price", "is required"}}},
+		{"required pointer to zero", func(o *order) { o.Price = intp(0) }, nil},
+		{"required empty slice", func(o *order) { o.Lines = []line{} }, Errors{{"lines", "is required"}}},
+		{"field without json name", func(o *order) { o.Internal = "" }, Errors{{"Internal", "is required"}}},
+		{"max string length", func(o *order) { o.Name = "Kopi Susu" }, Errors{{"name", "must be at most 5 characters long"}}},
+		{"max counts characters", func(o *order) { o.Name = "Kopi☕" }, nil},
+		{"min string length", func(o *order) { o.Code = "K" }, Errors{{"code", "must be at least 2 characters long"}}},
+		{"max slice length", func(o *order) { o.Tags = []string{"a", "b", "c"} }, Errors{{"tags", "must have at most 2 items"}}},
+		{"min on pointer", func(o *order) { o.Price = intp(-1) }, Errors{{"price", "must be at least 0"}}},
+		{"nil pointer skips min and max", func(o *order) { o.Discount = nil }, nil},
+		{"min and max on pointer within", func(o *order) { o.Discount = floatp(12.5) }, nil},
+		{"max on pointer", func(o *order) { o.Discount = floatp(100.5) }, Errors{{"discount", "must be at most 100"}}},
+		{"min on float pointer", func(o *order) { o.Discount = floatp(-0.1) }, Errors{{"discount", "must be at least 0"}}},
+		{"nil pointer skips gt", func(o *order) { o.Limit = nil }, nil},
+		{"gt on pointer", func(o *order) { o.Limit = intp(0) }, Errors{{"limit", "must be greater than 0"}}},
+		{"gt on pointer within", func(o *order) { o.Limit = intp(1) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(t, tt.change); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDive(t *testing.T) {
+	errs := check(t, func(o *order) {
+		o.Lines = []line{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 0, Quantity: 1},
+			{ProductID: 3, VariantID: -1, Quantity: 1000},
+		}
+	})
+	want := Errors{
+		{"lines[1].product_id", "must be greater than 0"},
+		{"lines[2].variant_id", "must be at least 0"},
+		{"lines[2].quantity", "must be at most 999"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+}
+
+func TestUnique(t *testing.T) {
+	errs := check(t, func(o *order) {
+		o.Lines = []line{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 1, VariantID: 2, Quantity: 1}, // another variant of 1
+			{ProductID: 1, Quantity: 3},               // repeats lines[0]
+			{ProductID: 1, VariantID: 2, Quantity: 1}, // repeats lines[1]
+		}
+	})
+	want := Errors{
+		{"lines[2].product_id", "duplicates lines[0]"},
+		{"lines[3].product_id", "duplicates lines[1]"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+}
+
+func TestEveryViolationReported(t *testing.T) {
+	errs := Struct(order{Name: "Kopi Susu", Price: intp(-5), Lines: []line{{}}})
+	want := Errors{
+		{"name", "must be at most 5 characters long"},
+		{"code", "must be at least 2 characters long"},
+		{"price", "must be at least 0"},
+		{"lines[0].product_id", "must be greater than 0"},
+		{"lines[0].quantity", "must be greater than 0"},
+		{"Internal", "is required"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatal("no violations should be no error")
+	}
+	errs.Add("lines[2].variant_id", "does not belong to the product")
+	errs.Add("name", "is required")
+
+	err := errs.Err()
+	var got Errors
+	if !errors.As(err, &got) || len(got) != 2 {
+		t.Fatalf("Err() = %v, want the two violations", err)
+	}
+	want := "validation failed: lines[2].variant_id does not belong to the product; name is required"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestMalformedTagPanics(t *testing.T) {
+	tests := map[string]interface{}{
+		"unknown rule": struct {
+			A int `validate:"between=1"`
+		}{},
+		"bad number": struct {
+			A int `validate:"min=one"`
+		}{},
+		"unique unknown field": struct {
+			A []line `validate:"unique=SKU"`
+		}{A: []line{{}}},
+	}
+	for name, v := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			Struct(v)
+		})
+	}
+}